@@ -0,0 +1,169 @@
+package easyworker
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// interval of checking queue of workers.
+	iSCALE_INTERVAL = 20 * time.Millisecond
+)
+
+/*
+Event of autoscaling, sent to callback of Config.SetAutoscale.
+*/
+type ScaleEvent struct {
+	// Number of workers before & after scaling.
+	From, To int
+
+	// Number of tasks were waiting for a worker, 0 for scaling down.
+	QueueDepth int
+
+	// Time of scaling.
+	Time time.Time
+}
+
+// options of autoscaling.
+type autoscaleOptions struct {
+	min, max int
+
+	// idle time before a worker is retired.
+	cooldown time.Duration
+
+	// callback for scaling events, can be nil.
+	onScale func(ScaleEvent)
+}
+
+/*
+Enable autoscaling for workers of EasyTask, EasyStream, TaskOf & EasyDAG.
+A run starts with number of workers in config (limited in range minWorkers..maxWorkers).
+If tasks are waiting for a free worker, workers are added (up to double of current workers, not over maxWorkers).
+A worker is retired if it's idle for cooldown, pool keeps at least minWorkers.
+onScale (can be nil) is called for each scaling, from goroutines of pool, it must be fast & safe for concurrent use.
+
+Example:
+
+	config, _ := NewConfig(fn, 2, 0, 0)
+	config.SetAutoscale(2, 32, 10*time.Second, func(e easyworker.ScaleEvent) {
+		log.Printf("workers: %d -> %d, queue: %d", e.From, e.To, e.QueueDepth)
+	})
+*/
+func (c *Config) SetAutoscale(minWorkers, maxWorkers int, cooldown time.Duration, onScale func(ScaleEvent)) error {
+	if minWorkers < 1 {
+		return fmt.Errorf("minimum number of workers is incorrect, %d", minWorkers)
+	}
+
+	if maxWorkers < minWorkers {
+		return fmt.Errorf("maximum number of workers is incorrect, %d < %d", maxWorkers, minWorkers)
+	}
+
+	if cooldown <= 0 {
+		return fmt.Errorf("cooldown of worker is incorrect, %s", cooldown)
+	}
+
+	c.scale = &autoscaleOptions{
+		min:      minWorkers,
+		max:      maxWorkers,
+		cooldown: cooldown,
+		onScale:  onScale,
+	}
+
+	return nil
+}
+
+/*
+Return number of workers when pool is started.
+*/
+func (a *autoscaleOptions) initial(workers int) int {
+	if workers < a.min {
+		return a.min
+	}
+	if workers > a.max {
+		return a.max
+	}
+	return workers
+}
+
+/*
+Check queue of workers periodically, add workers if tasks are waiting for a worker.
+Exit when pool is stopped.
+*/
+func (p *pool) autoscale() {
+	ticker := time.NewTicker(iSCALE_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.scaleUp()
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// add workers if tasks are waiting in input channel.
+func (p *pool) scaleUp() {
+	depth := len(p.inputCh)
+	if depth == 0 {
+		return
+	}
+
+	p.lock.Lock()
+	from := len(p.workers)
+
+	n := from
+	if n < 1 {
+		n = 1
+	}
+	if from+n > p.scale.max {
+		n = p.scale.max - from
+	}
+
+	for i := 0; i < n; i++ {
+		if !p.addWorker() {
+			break
+		}
+	}
+	to := len(p.workers)
+	p.lock.Unlock()
+
+	if to == from {
+		return
+	}
+
+	p.stats.scaleUps.Add(1)
+	if printLog {
+		log.Println("pool is scaled up, workers:", from, "->", to, ", queue:", depth)
+	}
+	if p.scale.onScale != nil {
+		p.scale.onScale(ScaleEvent{From: from, To: to, QueueDepth: depth, Time: time.Now()})
+	}
+}
+
+/*
+Retire an idle worker, called by worker after cooldown.
+Return false if pool has minimum number of workers, worker keeps running.
+*/
+func (p *pool) retire(id int64) bool {
+	p.lock.Lock()
+	from := len(p.workers)
+	if _, existed := p.workers[int(id)]; !existed || from <= p.scale.min {
+		p.lock.Unlock()
+		return false
+	}
+	delete(p.workers, int(id))
+	p.lock.Unlock()
+
+	p.stats.scaleDowns.Add(1)
+	if printLog {
+		log.Println("pool is scaled down, workers:", from, "->", from-1)
+	}
+	if p.scale.onScale != nil {
+		p.scale.onScale(ScaleEvent{From: from, To: from - 1, Time: time.Now()})
+	}
+
+	return true
+}
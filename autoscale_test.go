@@ -0,0 +1,143 @@
+package easyworker
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIncorrectAutoscale(t *testing.T) {
+	config := defaultConfig(add)
+
+	if err := config.SetAutoscale(0, 2, time.Second, nil); err == nil {
+		t.Error("missed checking minimum workers")
+	}
+
+	if err := config.SetAutoscale(3, 2, time.Second, nil); err == nil {
+		t.Error("missed checking maximum workers")
+	}
+
+	if err := config.SetAutoscale(1, 2, 0, nil); err == nil {
+		t.Error("missed checking cooldown")
+	}
+}
+
+func TestTaskAutoscale(t *testing.T) {
+	var (
+		running, peak atomic.Int64
+
+		lock   sync.Mutex
+		events []ScaleEvent
+	)
+
+	fn := func(n int) int {
+		cur := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			old := peak.Load()
+			if cur <= old || peak.CompareAndSwap(old, cur) {
+				break
+			}
+		}
+
+		time.Sleep(30 * time.Millisecond)
+		return n
+	}
+
+	config, _ := NewConfig(fn, 1, 0, 0)
+	config.SetAutoscale(1, 4, time.Second, func(e ScaleEvent) {
+		lock.Lock()
+		events = append(events, e)
+		lock.Unlock()
+	})
+
+	task, _ := NewTask(config)
+	for i := 0; i < 40; i++ {
+		task.AddTask(i)
+	}
+
+	ret, err := task.Run()
+	if err != nil {
+		t.Error("run task failed,", err)
+		return
+	}
+
+	for i := 0; i < 40; i++ {
+		if ret[i].([]any)[0] != i {
+			t.Error("incorrect result of task", i, ret[i])
+		}
+	}
+
+	if p := peak.Load(); p < 2 || p > 4 {
+		t.Error("incorrect number of running workers", p)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if len(events) == 0 {
+		t.Error("pool wasn't scaled up")
+	}
+	for _, e := range events {
+		if e.To <= e.From || e.To > 4 || e.QueueDepth < 1 {
+			t.Error("incorrect scaling event", e)
+		}
+	}
+
+	stats := task.Stats()
+	if stats.ScaleUps != int64(len(events)) || stats.Workers != 0 {
+		t.Error("incorrect stats", stats)
+	}
+}
+
+func TestStreamAutoscaleDown(t *testing.T) {
+	fn := func(n int) int {
+		time.Sleep(20 * time.Millisecond)
+		return n
+	}
+
+	inCh := make(chan []any)
+	outCh := make(chan any)
+
+	config, _ := NewConfig(fn, 1, 0, 0)
+	config.SetAutoscale(1, 4, 50*time.Millisecond, nil)
+
+	stream, _ := NewStream(config, inCh, outCh)
+	defer stream.Stop()
+
+	if err := stream.Run(); err != nil {
+		t.Error("run stream failed,", err)
+		return
+	}
+
+	go func() {
+		for i := 0; i < 30; i++ {
+			inCh <- []any{i}
+		}
+	}()
+
+	for i := 0; i < 30; i++ {
+		select {
+		case <-outCh:
+		case <-time.After(2 * time.Second):
+			t.Error("timed out")
+			return
+		}
+	}
+
+	if stats := stream.Stats(); stats.ScaleUps == 0 {
+		t.Error("stream wasn't scaled up", stats)
+	}
+
+	// idle workers are retired.
+	deadline := time.Now().Add(2 * time.Second)
+	for stream.Stats().Workers != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if stats := stream.Stats(); stats.Workers != 1 || stats.ScaleDowns == 0 {
+		t.Error("idle workers weren't retired", stats)
+	}
+}
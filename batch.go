@@ -0,0 +1,140 @@
+package easyworker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+/*
+Function processes a batch of tasks (params of each task) in batching mode.
+Return result of each task, same order & same length with batch. A result is an error if the task failed.
+If function returns an error, all tasks of batch failed (batch is retried by retry policy).
+*/
+type BatchFunc func(ctx context.Context, batch [][]any) ([]any, error)
+
+// options of batching mode.
+type batchOptions struct {
+	fun BatchFunc
+
+	// maximum number of tasks in a batch.
+	size int
+
+	// maximum time a task waits for a batch is full (EasyStream).
+	wait time.Duration
+}
+
+/*
+Enable batching mode for EasyTask & EasyStream, function in config is replaced by fun.
+Tasks are grouped into batches of maximum maxSize tasks, fun receives params of all tasks in a batch,
+results are fanned out to tasks (index of EasyTask or output of EasyStream).
+In EasyStream, a batch is sent to worker if it is full or tasks waited for maxWait.
+In EasyTask, tasks are known before running, a batch is made from waiting tasks immediately.
+Rate limit (if has) is applied for each task of batch (key of limiter is made from params of task),
+a batch is dispatched when all its tasks got token.
+Batching mode isn't supported by TaskOf & EasyDAG.
+
+Example:
+
+	config, _ := NewPoolConfig(2, 0, 0)
+	config.SetBatch(func(ctx context.Context, batch [][]any) ([]any, error) {
+		return bulkInsert(ctx, batch)
+	}, 100, 50*time.Millisecond)
+*/
+func (c *Config) SetBatch(fun BatchFunc, maxSize int, maxWait time.Duration) error {
+	if fun == nil {
+		return errors.New("batch function is nil")
+	}
+
+	if maxSize < 1 {
+		return fmt.Errorf("batch size is incorrect, %d", maxSize)
+	}
+
+	if maxWait < 0 {
+		return fmt.Errorf("batch wait time is incorrect, %s", maxWait)
+	}
+
+	c.batch = &batchOptions{
+		fun:  fun,
+		size: maxSize,
+		wait: maxWait,
+	}
+
+	return nil
+}
+
+/*
+Return function for calling batch function, data of task is params of tasks in batch.
+*/
+func (b *batchOptions) invoker() invoker {
+	fun := b.fun
+
+	return func(ctx context.Context, data any) (any, error) {
+		batch := data.([][]any)
+
+		return safeCall(func() (any, error) {
+			ret, err := fun(ctx, batch)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(ret) != len(batch) {
+				return nil, Permanent(fmt.Errorf("batch function returns %d results for %d tasks", len(ret), len(batch)))
+			}
+
+			return ret, nil
+		})
+	}
+}
+
+/*
+Take the next task from queue. In batching mode, tasks (up to batch size) are grouped into a task.
+Queue must not be empty.
+*/
+func (p *pool) nextTask(queue *priorityQueue) msg {
+	if p.batch == nil {
+		return queue.next()
+	}
+
+	items := make([]msg, 0, p.batch.size)
+	params := make([][]any, 0, p.batch.size)
+	for queue.Len() > 0 && len(items) < p.batch.size {
+		item := queue.next()
+		items = append(items, item)
+		params = append(params, item.data.([]any))
+	}
+
+	return msg{id: items[0].id, msgType: iTASK, data: params, items: items}
+}
+
+/*
+Fan out result of a batch to its tasks. Result of a single task is returned as is.
+*/
+func splitBatch(result msg) []msg {
+	if len(result.items) == 0 {
+		return []msg{result}
+	}
+
+	values, _ := result.data.([]any)
+
+	ret := make([]msg, len(result.items))
+	for i, item := range result.items {
+		r := result
+		r.id = item.id
+		r.input = item.data
+		r.items = nil
+
+		if result.msgType == iSUCCESS {
+			if err, ok := values[i].(error); ok {
+				r.msgType, r.data = iERROR, err
+			} else {
+				r.data = []any{values[i]}
+			}
+		}
+
+		ret[i] = r
+	}
+
+	return ret
+}
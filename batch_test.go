@@ -0,0 +1,253 @@
+package easyworker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sum params of each task, task has negative number is failed.
+func sumBatch(ctx context.Context, batch [][]any) ([]any, error) {
+	ret := make([]any, len(batch))
+	for i, params := range batch {
+		total := 0
+		for _, p := range params {
+			n := p.(int)
+			if n < 0 {
+				total = -1
+				break
+			}
+			total += n
+		}
+
+		if total < 0 {
+			ret[i] = errors.New("negative number")
+		} else {
+			ret[i] = total
+		}
+	}
+	return ret, nil
+}
+
+func TestIncorrectBatch(t *testing.T) {
+	config, _ := NewPoolConfig(1, 0, 0)
+
+	if err := config.SetBatch(nil, 1, 0); err == nil {
+		t.Error("missed checking nil function")
+	}
+
+	if err := config.SetBatch(sumBatch, 0, 0); err == nil {
+		t.Error("missed checking batch size")
+	}
+
+	if err := config.SetBatch(sumBatch, 1, -time.Second); err == nil {
+		t.Error("missed checking wait time")
+	}
+
+	config.SetBatch(sumBatch, 2, 0)
+	if _, err := NewDAG(config); err == nil {
+		t.Error("DAG accepts batching mode")
+	}
+
+	if _, err := NewTaskOf(func(ctx context.Context, n int) (int, error) { return n, nil }, config); err == nil {
+		t.Error("TaskOf accepts batching mode")
+	}
+}
+
+func TestTaskBatchRateLimitKey(t *testing.T) {
+	config, _ := NewPoolConfig(1, 0, 0)
+	config.SetBatch(sumBatch, 4, 0)
+	config.SetRateLimit(100, 1, func(args []any) any {
+		return args[0].(int) % 2
+	})
+
+	eWorker, _ := NewTask(config)
+	for i := 0; i < 4; i++ {
+		eWorker.AddTask(i, i)
+	}
+
+	r, err := eWorker.Run()
+	if err != nil {
+		t.Error("run task failed, ", err)
+		return
+	}
+
+	for i, v := range r {
+		if v.([]any)[0] != 2*i {
+			t.Error("incorrect result", i, v)
+		}
+	}
+
+	// a token for each task, the second task of each key waits.
+	if stats := eWorker.Stats(); stats.RateLimited != 1 {
+		t.Error("incorrect rate limit of batch", stats)
+	}
+}
+
+func TestTaskBatch(t *testing.T) {
+	var (
+		lock  sync.Mutex
+		sizes []int
+	)
+
+	config, _ := NewPoolConfig(2, 0, 0)
+	config.SetBatch(func(ctx context.Context, batch [][]any) ([]any, error) {
+		lock.Lock()
+		sizes = append(sizes, len(batch))
+		lock.Unlock()
+		return sumBatch(ctx, batch)
+	}, 3, 0)
+
+	eWorker, _ := NewTask(config)
+	for i := 0; i < 7; i++ {
+		if i == 4 {
+			eWorker.AddTask(i, -1)
+		} else {
+			eWorker.AddTask(i, i)
+		}
+	}
+
+	r, err := eWorker.Run()
+	if err != nil {
+		t.Error("run task failed, ", err)
+		return
+	}
+
+	for i, v := range r {
+		if i == 4 {
+			if _, ok := v.(error); !ok {
+				t.Error("incorrect failed result", v)
+			}
+		} else if v.([]any)[0] != 2*i {
+			t.Error("incorrect result", i, v)
+		}
+	}
+
+	if len(sizes) != 3 || sizes[0]+sizes[1]+sizes[2] != 7 {
+		t.Error("incorrect batches", sizes)
+	}
+
+	results := eWorker.GetResults()
+	if len(results[6].Args) != 2 || results[6].Args[0] != 6 {
+		t.Error("incorrect args of task in batch", results[6])
+	}
+}
+
+func TestTaskBatchFailed(t *testing.T) {
+	config, _ := NewPoolConfig(1, 0, 0)
+	config.SetBatch(func(ctx context.Context, batch [][]any) ([]any, error) {
+		return nil, errors.New("batch failed")
+	}, 2, 0)
+
+	eWorker, _ := NewTask(config)
+	eWorker.AddTask(1)
+	eWorker.AddTask(2)
+	eWorker.AddTask(3)
+
+	r, _ := eWorker.Run()
+	for _, v := range r {
+		if err, ok := v.(error); !ok || err.Error() != "batch failed" {
+			t.Error("incorrect result of failed batch", v)
+		}
+	}
+}
+
+func TestStreamBatch(t *testing.T) {
+	inCh := make(chan []any)
+	outCh := make(chan any)
+
+	var (
+		lock  sync.Mutex
+		sizes []int
+	)
+
+	config, _ := NewPoolConfig(1, 0, 0)
+	config.SetBatch(func(ctx context.Context, batch [][]any) ([]any, error) {
+		lock.Lock()
+		sizes = append(sizes, len(batch))
+		lock.Unlock()
+		return sumBatch(ctx, batch)
+	}, 3, 50*time.Millisecond)
+
+	eWorker, _ := NewStream(config, inCh, outCh)
+	eWorker.Run()
+	defer eWorker.Stop()
+
+	go func() {
+		for i := 1; i <= 4; i++ {
+			inCh <- []any{i}
+		}
+	}()
+
+	sum := 0
+	for i := 0; i < 4; i++ {
+		select {
+		case r := <-outCh:
+			sum += r.([]any)[0].(int)
+		case <-time.After(time.Second):
+			t.Error("timed out")
+			return
+		}
+	}
+
+	if sum != 10 {
+		t.Error("incorrect results", sum)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// the last task is sent after max wait time.
+	if len(sizes) != 2 || sizes[0] != 3 || sizes[1] != 1 {
+		t.Error("incorrect batches", sizes)
+	}
+}
+
+func TestStreamBatchMaxWait(t *testing.T) {
+	inCh := make(chan []any)
+	outCh := make(chan any)
+
+	// workers are blocked until the old task & higher priority tasks are queued.
+	gate := make(chan struct{})
+
+	config, _ := NewPoolConfig(4, 0, 0)
+	config.SetBatch(func(ctx context.Context, batch [][]any) ([]any, error) {
+		<-gate
+		return sumBatch(ctx, batch)
+	}, 2, 200*time.Millisecond)
+
+	eWorker, _ := NewStream(config, inCh, outCh)
+	eWorker.Run()
+	defer eWorker.Stop()
+
+	// busy workers, buffered batches & a batch is waiting for a worker.
+	for i := 0; i < 18; i++ {
+		inCh <- []any{2}
+	}
+
+	start := time.Now()
+	inCh <- []any{1}
+
+	time.Sleep(150 * time.Millisecond)
+	eWorker.SendWithPriority(10, 3)
+	eWorker.SendWithPriority(10, 3)
+	close(gate)
+
+	for i := 0; i < 21; i++ {
+		select {
+		case r := <-outCh:
+			// a full batch of higher priority tasks is sent first,
+			// the old task waits max wait time from its adding, not from sending the full batch.
+			if r.([]any)[0] == 1 {
+				if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+					t.Error("task waited longer than max wait time,", elapsed)
+				}
+			}
+		case <-time.After(time.Second):
+			t.Error("timed out")
+			return
+		}
+	}
+}
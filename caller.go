@@ -1,11 +1,32 @@
 package easyworker
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"reflect"
 )
 
+/*
+Error of a task when user function was panic.
+Value is the value passed to panic, used for classifying panic in RetryPolicy.Retryable.
+*/
+type PanicError struct {
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("user function was panic, %v", e.Value)
+}
+
+/*
+Return panic value if it is an error, used by errors.Is/errors.As.
+*/
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
 /*
 call user's function througth reflect.
 */
@@ -16,7 +37,7 @@ func invokeFun(fun any, args ...any) (ret []any, err error) {
 			if printLog {
 				log.Println("user function was panic, ", r)
 			}
-			err = fmt.Errorf("user function was panic, %s", r)
+			err = &PanicError{Value: r}
 		}
 	}()
 
@@ -27,10 +48,10 @@ func invokeFun(fun any, args ...any) (ret []any, err error) {
 	numIn := fnType.NumIn()
 
 	if numIn > len(args) {
-		return nil, fmt.Errorf("function must have minimum %d params. Have %d", numIn, len(args))
+		return nil, Permanent(fmt.Errorf("function must have minimum %d params. Have %d", numIn, len(args)))
 	}
 	if numIn != len(args) && !fnType.IsVariadic() {
-		return nil, fmt.Errorf("func must have %d params. Have %d", numIn, len(args))
+		return nil, Permanent(fmt.Errorf("func must have %d params. Have %d", numIn, len(args)))
 	}
 	params := make([]reflect.Value, len(args))
 	for i := 0; i < len(args); i++ {
@@ -42,13 +63,13 @@ func invokeFun(fun any, args ...any) (ret []any, err error) {
 		}
 		argValue := reflect.ValueOf(args[i])
 		if !argValue.IsValid() {
-			return nil, fmt.Errorf("func Param[%d] must be %s. Have %s", i, inType, argValue.String())
+			return nil, Permanent(fmt.Errorf("func Param[%d] must be %s. Have %s", i, inType, argValue.String()))
 		}
 		argType := argValue.Type()
 		if argType.ConvertibleTo(inType) {
 			params[i] = argValue.Convert(inType)
 		} else {
-			return nil, fmt.Errorf("method Param[%d] must be %s. Have %s", i, inType, argType)
+			return nil, Permanent(fmt.Errorf("method Param[%d] must be %s. Have %s", i, inType, argType))
 		}
 	}
 
@@ -75,3 +96,31 @@ func verifyFunc(fun any) error {
 	}
 	return nil
 }
+
+/*
+check if the first param of function is context.Context.
+*/
+func acceptContext(fun any) bool {
+	fnType := reflect.TypeOf(fun)
+	if fnType == nil || fnType.Kind() != reflect.Func || fnType.NumIn() < 1 {
+		return false
+	}
+
+	return fnType.In(0) == reflect.TypeOf((*context.Context)(nil)).Elem()
+}
+
+/*
+call user's function directly, catch if panic by user code.
+*/
+func safeCall(fun func() (any, error)) (ret any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if printLog {
+				log.Println("user function was panic, ", r)
+			}
+			err = &PanicError{Value: r}
+		}
+	}()
+
+	return fun()
+}
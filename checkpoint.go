@@ -0,0 +1,191 @@
+package easyworker
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// a line of checkpoint file, result of a succeeded task.
+type checkpointEntry struct {
+	Key    string            `json:"key"`
+	Values []json.RawMessage `json:"values"`
+}
+
+/*
+Checkpoint of EasyTask, an append-only JSON Lines file stores results of succeeded tasks.
+*/
+type checkpoint struct {
+	lock sync.Mutex
+
+	// return key of task, nil is index of task.
+	key func(index int, args []any) string
+
+	// types of return values of user function, nil if unknown (values are decoded to default types).
+	types []reflect.Type
+
+	// results of succeeded tasks in file.
+	done map[string][]json.RawMessage
+
+	// the last line of file isn't ended by a new line.
+	broken bool
+
+	file *os.File
+}
+
+/*
+Open checkpoint file, results in file are loaded. File is created if it doesn't exist.
+*/
+func openCheckpoint(path string, key func(index int, args []any) string, fun any) (ret *checkpoint, err error) {
+	ret = &checkpoint{
+		key:  key,
+		done: make(map[string][]json.RawMessage),
+	}
+
+	if fnType := reflect.TypeOf(fun); fnType != nil && fnType.Kind() == reflect.Func {
+		ret.types = make([]reflect.Type, fnType.NumOut())
+		for i := range ret.types {
+			ret.types[i] = fnType.Out(i)
+		}
+	}
+
+	if err = ret.load(path); err != nil {
+		return nil, err
+	}
+
+	if ret.file, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644); err != nil {
+		return nil, err
+	}
+
+	// end the broken line, new records aren't merged with it.
+	if ret.broken {
+		if _, err = ret.file.Write([]byte{'\n'}); err != nil {
+			ret.file.Close()
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// load results from file, broken lines (process was killed while writing) are ignored.
+func (c *checkpoint) load(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for scanner.Scan() {
+		var entry checkpointEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			if printLog {
+				log.Println("checkpoint, ignore broken line:", err)
+			}
+			continue
+		}
+		c.done[entry.Key] = entry.Values
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+	c.broken = last[0] != '\n'
+
+	return nil
+}
+
+// return key of task.
+func (c *checkpoint) keyOf(index int, args []any) string {
+	if c.key == nil {
+		return strconv.Itoa(index)
+	}
+	return c.key(index, args)
+}
+
+/*
+Return stored result of task, false if task wasn't succeeded or result cannot be decoded.
+*/
+func (c *checkpoint) lookup(index int, args []any) ([]any, bool) {
+	raw, existed := c.done[c.keyOf(index, args)]
+	if !existed {
+		return nil, false
+	}
+
+	if c.types != nil && len(c.types) != len(raw) {
+		return nil, false
+	}
+
+	ret := make([]any, len(raw))
+	for i, r := range raw {
+		if c.types == nil {
+			if err := json.Unmarshal(r, &ret[i]); err != nil {
+				return nil, false
+			}
+			continue
+		}
+
+		v := reflect.New(c.types[i])
+		if err := json.Unmarshal(r, v.Interface()); err != nil {
+			return nil, false
+		}
+		ret[i] = v.Elem().Interface()
+	}
+
+	return ret, true
+}
+
+/*
+Append result of a succeeded task to file.
+*/
+func (c *checkpoint) record(index int, args []any, values []any) error {
+	entry := checkpointEntry{
+		Key:    c.keyOf(index, args),
+		Values: make([]json.RawMessage, len(values)),
+	}
+
+	for i, v := range values {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("cannot store result of task %q, %w", entry.Key, err)
+		}
+		entry.Values[i] = raw
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, err = c.file.Write(append(line, '\n'))
+	return err
+}
+
+func (c *checkpoint) close() error {
+	return c.file.Close()
+}
@@ -0,0 +1,102 @@
+package easyworker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+
+	var calls atomic.Int64
+	fn := func(a int, suffix string) (string, int) {
+		calls.Add(1)
+		if a%3 == 0 {
+			panic("panic from user func")
+		}
+		return suffix, a * 2
+	}
+
+	newTask := func() *EasyTask {
+		config, _ := NewConfig(fn, 2, 0, 0)
+		eWorker, _ := NewTask(config)
+		if err := eWorker.SetCheckpoint(path, func(index int, args []any) string {
+			return args[1].(string)
+		}); err != nil {
+			t.Error("set checkpoint failed, ", err)
+		}
+
+		for i := 1; i <= 4; i++ {
+			eWorker.AddTask(i, "task"+string(rune('0'+i)))
+		}
+		return &eWorker
+	}
+
+	if _, err := newTask().Run(); err != nil {
+		t.Error("run task failed, ", err)
+		return
+	}
+
+	if calls.Load() != 4 {
+		t.Error("incorrect number of calls", calls.Load())
+	}
+
+	// simulate a broken line when process was killed.
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString(`{"key": "task9", "val`)
+	f.Close()
+
+	eWorker := newTask()
+	r, err := eWorker.Run()
+	if err != nil {
+		t.Error("resume task failed, ", err)
+		return
+	}
+
+	// only failed task is run again.
+	if calls.Load() != 5 {
+		t.Error("succeeded tasks are run again", calls.Load())
+	}
+
+	if v := r[3].([]any); v[0] != "task4" || v[1] != 8 {
+		t.Error("incorrect stored result", v)
+	}
+
+	if _, ok := r[2].(error); !ok {
+		t.Error("failed task isn't run again", r[2])
+	}
+
+	if eWorker.GetResults()[0].Attempts != 0 {
+		t.Error("restored result has attempts", eWorker.GetResults()[0])
+	}
+
+	// new records aren't merged with the broken line.
+	eWorker.AddTask(5, "task5")
+	eWorker.Run()
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 5 || lines[4] != `{"key":"task5","values":["task5",10]}` {
+		t.Error("incorrect records", lines)
+	}
+}
+
+func TestCheckpointIndexKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+
+	eWorker, _ := NewTask(defaultConfig(add))
+	if err := eWorker.SetCheckpoint("", nil); err == nil {
+		t.Error("missed checking empty path")
+	}
+	eWorker.SetCheckpoint(path, nil)
+	eWorker.AddTask(1, 2)
+	eWorker.Run()
+
+	data, _ := os.ReadFile(path)
+	if strings.TrimSpace(string(data)) != `{"key":"0","values":[3]}` {
+		t.Error("incorrect checkpoint record", string(data))
+	}
+}
@@ -2,6 +2,7 @@ package easyworker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync/atomic"
@@ -53,6 +54,9 @@ func getNewChildId() int64 {
 A child struct that hold information a bout task, restart strategy.
 */
 type Child struct {
+	links
+	monitors
+
 	id           int64
 	restart_type int
 	cmdCh        chan msg
@@ -64,6 +68,7 @@ type Child struct {
 	fun    any
 	params []any
 	ctx    context.Context
+	cancel context.CancelFunc
 
 	result any
 }
@@ -105,19 +110,62 @@ func (c *Child) Id() int64 {
 Start goroutine to execute task.
 */
 func (c *Child) run() {
+	c.begin()
 	go c.run_task()
 }
 
+/*
+Start child without supervisor.
+Child isn't restarted if it panic, use Supervisor for restart strategy.
+*/
+func (c *Child) Start() error {
+	if !c.canRun() {
+		return errors.New("child cannot run, it was stopped")
+	}
+
+	c.run()
+	return nil
+}
+
+/*
+Stop child. Child after process your function will check the signal and stop.
+Context of child (if has) is cancelled.
+*/
+func (c *Child) Stop() error {
+	c.stop()
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	return nil
+}
+
+/*
+Return state of child (STANDBY, RUNNING, RESTARTING, STOPPED, FORCE_QUIT).
+*/
+func (c *Child) State() int64 {
+	return c.getState()
+}
+
 /*
 Run task.
 */
 func (c *Child) run_task() {
+	var (
+		err      error
+		attempts int
+	)
+
 	defer func() {
 		msg := msg{
 			id:      int(c.id),
 			msgType: iCHILD_TASK_DONE,
 		}
 
+		signal := SIGNAL_DONE
+		var reason any
+
 		// catch if panic by child code.
 		if r := recover(); r != nil {
 			if printLog {
@@ -126,19 +174,34 @@ func (c *Child) run_task() {
 			msg.msgType = iCHILD_PANIC
 			msg.data = r
 			c.incFailed()
+			signal, reason = SIGNAL_FAILED, r
 		} else {
 			c.updateState(STOPPED)
+			if err != nil {
+				signal, reason = SIGNAL_FAILED, err
+			}
 		}
 
-		c.cmdCh <- msg
-	}()
+		var lastErr error
+		if signal == SIGNAL_FAILED {
+			lastErr = fmt.Errorf("child failed, reason: %v", reason)
+		}
+		c.end(GoSignal{Signal: signal, Attempts: attempts}, lastErr)
 
-	var err error
+		propagateExit(c, signal, reason)
+
+		// cmdCh is nil if child is run without supervisor.
+		if c.cmdCh != nil {
+			c.cmdCh <- msg
+		}
+	}()
 
 	c.updateState(RUNNING)
 
 l:
 	for {
+		attempts++
+
 		// call user define function.
 		c.result, err = invokeFun(c.fun, c.params...)
 
@@ -178,6 +241,41 @@ func (c *Child) stop() {
 	c.updateState(FORCE_QUIT)
 }
 
+/*
+Link child with other process (Go, Child).
+If one of them failed, the other will be cancelled unless it traps exits.
+*/
+func (c *Child) Link(other Linkable) error {
+	return Link(c, other)
+}
+
+/*
+Remove link between child and other process.
+*/
+func (c *Child) Unlink(other Linkable) {
+	Unlink(c, other)
+}
+
+func (c *Child) getLinks() *links {
+	return &c.links
+}
+
+/*
+Cancel child because a linked process failed.
+Child won't be restarted and its context (if has) is cancelled.
+*/
+func (c *Child) kill(reason any) {
+	if printLog {
+		log.Println(c.id, "child is cancelled, reason:", reason)
+	}
+
+	c.stop()
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
 func (c *Child) updateState(newStatus int) {
 	c.state.Store(int64(newStatus))
 }
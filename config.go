@@ -1,6 +1,11 @@
 package easyworker
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
 
 var (
 	// use to store last id of supervisor. id is auto_increment.
@@ -18,11 +23,39 @@ type Config struct {
 	// number of workers (goroutines)
 	worker int
 
-	// retry times, if function was failed, worker will try again.
-	retry int
+	// retry policy, if function was failed, worker will try again.
+	retry RetryPolicy
+
+	// stop run at the first failed task.
+	failFast bool
+
+	// timeout for each call of user function, 0 is no timeout.
+	timeout time.Duration
+
+	// aging interval of task priority, 0 is no aging.
+	aging time.Duration
+
+	// rate limiter for dispatching tasks, nil is no limit.
+	limiter *rateLimiter
+
+	// progress callback & interval of updates.
+	onProgress       func(Progress)
+	progressInterval time.Duration
 
-	// sleep time before rerun
-	retrySleep int
+	// batching mode, nil is disabled.
+	batch *batchOptions
+
+	// autoscaling of workers, nil is fixed number of workers.
+	scale *autoscaleOptions
+
+	// memoization of stream, nil is disabled.
+	memo *memoOptions
+
+	// restart strategy of dead workers.
+	restart int
+
+	// policy for failed tasks of EasyTask.Run.
+	failure int
 }
 
 /*
@@ -44,6 +77,23 @@ func NewConfig(fun any, numWorkers int, retryTimes int, retrySleep int) (ret Con
 		return
 	}
 
+	if ret, err = NewPoolConfig(numWorkers, retryTimes, retrySleep); err != nil {
+		return
+	}
+	ret.fun = fun
+
+	return
+}
+
+/*
+Make a configuration holder without user function.
+Used for generic task (TaskOf), function is passed directly to NewTaskOf.
+
+Example:
+
+	config,_ := NewPoolConfig(3, 0, 0)
+*/
+func NewPoolConfig(numWorkers int, retryTimes int, retrySleep int) (ret Config, err error) {
 	if numWorkers < 1 {
 		err = fmt.Errorf("number of workers is incorrect, %d", numWorkers)
 		return
@@ -60,15 +110,194 @@ func NewConfig(fun any, numWorkers int, retryTimes int, retrySleep int) (ret Con
 	}
 
 	ret = Config{
-		fun:        fun,
-		worker:     numWorkers,
-		retry:      retryTimes,
-		retrySleep: retrySleep,
+		worker: numWorkers,
+		retry: RetryPolicy{
+			MaxAttempts: retryTimes + 1,
+			Backoff:     BACKOFF_FIXED,
+			Delay:       time.Duration(retrySleep) * time.Millisecond,
+		},
+		aging: iDEFAULT_AGING,
 	}
 
 	return
 }
 
+/*
+Set retry policy for EasyTask, EasyStream & TaskOf.
+Policy replaces retryTimes & retrySleep of NewConfig.
+
+Example:
+
+	config.SetRetryPolicy(easyworker.RetryPolicy{
+		MaxAttempts: 5,
+		Backoff:     easyworker.BACKOFF_EXPONENTIAL,
+		Delay:       100 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Jitter:      easyworker.JITTER_FULL,
+		Deadline:    time.Minute,
+	})
+*/
+func (c *Config) SetRetryPolicy(policy RetryPolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+
+	c.retry = policy
+	return nil
+}
+
+/*
+Set classifier for failures of user function, same with Retryable of RetryPolicy.
+Task isn't retried if classifier returns false or error was marked by Permanent.
+Panic is passed as *PanicError, panic value is in field Value.
+
+Example:
+
+	config.SetRetryable(func(err error) bool {
+		return !errors.Is(err, ErrNotFound)
+	})
+*/
+func (c *Config) SetRetryable(retryable func(err error) bool) {
+	c.retry.Retryable = retryable
+}
+
+/*
+Enable/disable fail fast mode for EasyTask & TaskOf.
+In fail fast mode, the first failed task (after retries) cancels pending & running tasks,
+Run returns error of that task with partial results.
+*/
+func (c *Config) SetFailFast(enable bool) {
+	c.failFast = enable
+}
+
+/*
+Set restart strategy for workers of EasyTask, EasyStream, TaskOf & EasyDAG, same strategies with Child.
+A worker is dead if it was panic outside of user function (ex: in retry classifier), its running task is failed with PanicError.
+ALWAYS_RESTART (default) & ERROR_RESTART replace dead worker by a new worker (counted in Restarts of Stats),
+NO_RESTART removes dead worker, a run is stopped with ErrNoWorkers if all workers were dead.
+*/
+func (c *Config) SetWorkerRestart(restart int) error {
+	if restart < ALWAYS_RESTART || restart > NO_RESTART {
+		return fmt.Errorf("in correct restart type, input: %d", restart)
+	}
+
+	c.restart = restart
+	return nil
+}
+
+/*
+Set timeout for each call of user function (EasyTask, EasyStream & TaskOf), 0 is no timeout.
+If timeout is expired, context passed to user function is cancelled, the attempt is failed with ErrTimeout
+(can be retried) and worker moves on. Goroutine of the attempt is abandoned until user function returns,
+number of abandoned goroutines is in Stats.
+*/
+func (c *Config) SetTimeout(timeout time.Duration) error {
+	if timeout < 0 {
+		return fmt.Errorf("timeout is incorrect, %s", timeout)
+	}
+
+	c.timeout = timeout
+	return nil
+}
+
+/*
+Set aging for task priority (EasyTask & EasyStream), default is 1 second.
+Priority of a waiting task is increased by 1 after each aging interval, low priority tasks aren't starved
+by a continuous flow of high priority tasks. 0 is no aging (strict priority).
+*/
+func (c *Config) SetPriorityAging(aging time.Duration) error {
+	if aging < 0 {
+		return fmt.Errorf("aging is incorrect, %s", aging)
+	}
+
+	c.aging = aging
+	return nil
+}
+
+/*
+Set rate limit for dispatching tasks (EasyTask, EasyStream, TaskOf & EasyDAG) by a token bucket.
+rate is number of tasks per second, burst is maximum number of tasks can be dispatched at once.
+If key is not nil, each key (return from params of task, must be comparable) has its own bucket,
+tasks of a throttled key don't block tasks of other keys (order of tasks of a key is kept).
+rate = 0 is no limit. Tasks & streams are made from same config share the limit.
+Number of waiting tasks, waiting time & current rate are in Stats.
+
+Example:
+
+	// 10 requests per second for each host.
+	config.SetRateLimit(10, 1, func(args []any) any {
+		return args[0].(string)
+	})
+*/
+func (c *Config) SetRateLimit(rate float64, burst int, key func(args []any) any) error {
+	if rate < 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return fmt.Errorf("rate is incorrect, %v", rate)
+	}
+
+	if rate == 0 {
+		c.limiter = nil
+		return nil
+	}
+
+	if burst < 1 {
+		return fmt.Errorf("burst is incorrect, %d", burst)
+	}
+
+	c.limiter = newRateLimiter(rate, burst, key)
+	return nil
+}
+
+/*
+Set callback for receiving progress of runs (EasyTask & TaskOf), fn = nil is no progress.
+Progress is sent after each interval and when run is done.
+Callback is called in result loop of run, it must not block.
+
+Example:
+
+	config.SetProgress(time.Second, func(p easyworker.Progress) {
+		fmt.Printf("%d/%d done, %d failed, ETA: %s\n", p.Completed+p.Failed, p.Total, p.Failed, p.ETA)
+	})
+*/
+func (c *Config) SetProgress(interval time.Duration, fn func(Progress)) error {
+	if fn != nil && interval <= 0 {
+		return fmt.Errorf("interval of progress is incorrect, %s", interval)
+	}
+
+	c.onProgress = fn
+	c.progressInterval = interval
+	return nil
+}
+
+/*
+Return function for calling user function through reflect.
+Data of task is slice of params.
+If the first param of user function is context.Context, context of run is added to params.
+In batching mode, batch function is called.
+*/
+func (c Config) invoker() invoker {
+	if c.batch != nil {
+		return c.batch.invoker()
+	}
+
+	fun := c.fun
+	withCtx := acceptContext(fun)
+
+	return func(ctx context.Context, data any) (any, error) {
+		args := data.([]any)
+
+		// add context if user doesn't pass it.
+		if withCtx {
+			if len(args) == 0 {
+				args = []any{ctx}
+			} else if _, ok := args[0].(context.Context); !ok {
+				args = append([]any{ctx}, args...)
+			}
+		}
+
+		return invokeFun(fun, args...)
+	}
+}
+
 /*
 Turn on/off print log to logger.
 enable = true, print log.
@@ -73,3 +73,11 @@ func TestPrintLog(t *testing.T) {
 		t.Error("Enable Log failed.")
 	}
 }
+
+func TestIncorrectTimeout(t *testing.T) {
+	config := defaultConfig(add)
+
+	if err := config.SetTimeout(-1); err == nil {
+		t.Error("incorrect timeout is passed")
+	}
+}
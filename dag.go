@@ -0,0 +1,368 @@
+package easyworker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+var (
+	// Error of a task was skipped because one of its dependencies failed, used with errors.Is.
+	ErrDependencyFailed = errors.New("dependency failed")
+)
+
+// task of DAG.
+type dagNode struct {
+	id     string
+	deps   []string
+	params []any
+
+	// append results of dependencies to params.
+	inject bool
+}
+
+/*
+Run tasks with dependencies (directed acyclic graph) on a worker pool.
+A task is run after all its dependencies done, tasks are ready at same time are run in parallel.
+If a task failed, its dependents (direct & indirect) aren't run and have an error wraps ErrDependencyFailed.
+*/
+type EasyDAG struct {
+	id int
+
+	// config input by user.
+	config Config
+
+	// tasks, in order of adding.
+	nodes []dagNode
+
+	// index of task by id.
+	index map[string]int
+
+	// counters for Stats.
+	stats counters
+}
+
+/*
+Make new EasyDAG.
+Config is made by NewConfig, all tasks are run by function in config.
+
+Example:
+
+	config, _ := NewConfig(fn, 3, 0, 0)
+	dag, _ := NewDAG(config)
+*/
+func NewDAG(config Config) (ret *EasyDAG, err error) {
+	if err = verifyFunc(config.fun); err != nil {
+		return
+	}
+
+	if config.worker < 1 {
+		err = errors.New("config isn't made by NewConfig")
+		return
+	}
+
+	if config.batch != nil {
+		err = errors.New("batching mode isn't supported by EasyDAG")
+		return
+	}
+
+	// auto incremental number.
+	taskLastId++
+
+	ret = &EasyDAG{
+		id:     taskLastId,
+		config: config,
+		index:  make(map[string]int),
+	}
+
+	return
+}
+
+/*
+Add a task with id & ids of dependencies.
+Dependencies can be added after the task, they are checked when running.
+
+Example:
+
+	dag.AddTask("load_a", nil, "a.csv")
+	dag.AddTask("load_b", nil, "b.csv")
+	dag.AddTask("merge", []string{"load_a", "load_b"}, "out.csv")
+*/
+func (d *EasyDAG) AddTask(id string, deps []string, params ...any) error {
+	return d.add(id, deps, false, params)
+}
+
+/*
+Add a task, results of dependencies are injected as arguments.
+Return values of each dependency are appended to params, in order of deps.
+
+Example:
+
+	// merge is called with merge("out.csv", resultOfA, resultOfB)
+	dag.AddTaskInject("merge", []string{"load_a", "load_b"}, "out.csv")
+*/
+func (d *EasyDAG) AddTaskInject(id string, deps []string, params ...any) error {
+	return d.add(id, deps, true, params)
+}
+
+func (d *EasyDAG) add(id string, deps []string, inject bool, params []any) error {
+	if _, existed := d.index[id]; existed {
+		return fmt.Errorf("task %q is existed", id)
+	}
+
+	d.index[id] = len(d.nodes)
+	d.nodes = append(d.nodes, dagNode{
+		id:     id,
+		deps:   append([]string(nil), deps...),
+		params: append([]any(nil), params...),
+		inject: inject,
+	})
+
+	return nil
+}
+
+/*
+Run all tasks and wait for all tasks done.
+Before starting, dependencies are checked, Run returns an error if a dependency doesn't exist or has a cycle.
+Results are same order with adding tasks, index of Result is order of adding, use IndexOf to get index of a task.
+If context is cancelled, tasks aren't dispatched anymore, tasks without result are marked with ErrCancelled & Run returns the error.
+In fail fast mode, error of the first failed task is returned with partial results.
+
+Example:
+
+	results, err := dag.Run(context.Background())
+*/
+func (d *EasyDAG) Run(ctx context.Context) (ret []Result[[]any], retErr error) {
+	ret = make([]Result[[]any], 0)
+
+	if len(d.nodes) < 1 {
+		retErr = errors.New("need tasks to run")
+		return
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// number of dependencies aren't done & dependents of each task.
+	pending, dependents, err := d.graph()
+	if err != nil {
+		retErr = err
+		return
+	}
+
+	n := len(d.nodes)
+	ret = make([]Result[[]any], n)
+	finished := make([]bool, n)
+	counter := 0
+
+	// tasks are ready to run.
+	ready := make([]msg, 0, n)
+	for i := range d.nodes {
+		if pending[i] == 0 {
+			ready = append(ready, d.task(i, ret))
+		}
+	}
+
+	// Start workers
+	workers := startPool(ctx, d.config, d.config.invoker(), &d.stats)
+	defer workers.stop()
+
+	// mark task is done, dependents are ready or skipped.
+	var finish func(i int, failed bool)
+	finish = func(i int, failed bool) {
+		for _, j := range dependents[i] {
+			if finished[j] {
+				continue
+			}
+
+			if failed {
+				finished[j] = true
+				counter++
+				ret[j] = Result[[]any]{
+					Index:    j,
+					Err:      fmt.Errorf("%w, task %q failed", ErrDependencyFailed, d.nodes[i].id),
+					Args:     d.nodes[j].params,
+					WorkerId: -1,
+				}
+				finish(j, true)
+				continue
+			}
+
+			pending[j]--
+			if pending[j] == 0 {
+				ready = append(ready, d.task(j, ret))
+			}
+		}
+	}
+
+	// ready tasks are waiting for rate limit or a worker.
+	th := newThrottle(workers)
+	defer th.stop()
+
+	for counter < n {
+		// tasks of throttled keys wait for token, other ready tasks aren't blocked.
+		for len(ready) > 0 {
+			if _, ok := th.next(); ok {
+				break
+			}
+			th.admit(ready[0])
+			ready = ready[1:]
+		}
+
+		var out chan msg
+		next, ok := th.next()
+		if ok {
+			out = workers.inputCh
+		}
+
+		select {
+		case out <- next:
+			th.pop()
+			workers.dispatched()
+		case <-th.wake():
+			th.release()
+		case result := <-workers.resultCh:
+			switch result.msgType {
+			case iFATAL_ERROR: // worker panic, task of worker was answered.
+				if workers.alive() == 0 && retErr == nil {
+					retErr = ErrNoWorkers
+					workers.cancel(retErr)
+				}
+				continue
+			case iQUIT:
+				continue
+			}
+
+			if finished[result.id] {
+				continue
+			}
+
+			finished[result.id] = true
+			counter++
+			ret[result.id] = newResult[[]any](result)
+
+			failed := result.msgType != iSUCCESS
+			if failed && printLog {
+				log.Println("dag task", d.nodes[result.id].id, "is failed, error:", result.data)
+			}
+
+			if failed && d.config.failFast && retErr == nil {
+				retErr = ret[result.id].Err
+				workers.cancel(fmt.Errorf("fail fast, task %q failed, reason: %v", d.nodes[result.id].id, retErr))
+			}
+
+			finish(result.id, failed)
+		case <-workers.ctx.Done():
+			err := fmt.Errorf("%w, %w", ErrCancelled, context.Cause(workers.ctx))
+			for i := range d.nodes {
+				if !finished[i] {
+					ret[i] = Result[[]any]{Index: i, Err: err, Args: d.nodes[i].params, WorkerId: -1}
+				}
+			}
+
+			// error of fail fast mode or dead workers is kept.
+			if retErr == nil {
+				retErr = err
+			}
+			return
+		}
+	}
+
+	return
+}
+
+/*
+Return index of task by id, -1 if task doesn't exist.
+*/
+func (d *EasyDAG) IndexOf(id string) int {
+	if i, existed := d.index[id]; existed {
+		return i
+	}
+	return -1
+}
+
+/*
+Return statistic of EasyDAG.
+*/
+func (d *EasyDAG) Stats() Stats {
+	return d.stats.snapshot()
+}
+
+/*
+Build graph of tasks & check dependencies (Kahn's algorithm).
+Return number of dependencies & dependents of each task.
+*/
+func (d *EasyDAG) graph() (pending []int, dependents [][]int, err error) {
+	n := len(d.nodes)
+	pending = make([]int, n)
+	dependents = make([][]int, n)
+
+	for i, node := range d.nodes {
+		for _, dep := range node.deps {
+			j, existed := d.index[dep]
+			if !existed {
+				return nil, nil, fmt.Errorf("dependency %q of task %q doesn't exist", dep, node.id)
+			}
+			pending[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	// remove tasks without dependencies, tasks are left have a cycle.
+	remain := append([]int(nil), pending...)
+	queue := make([]int, 0, n)
+	for i := range remain {
+		if remain[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+
+	visited := 0
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		visited++
+
+		for _, j := range dependents[i] {
+			remain[j]--
+			if remain[j] == 0 {
+				queue = append(queue, j)
+			}
+		}
+	}
+
+	if visited < n {
+		cycle := make([]string, 0, n-visited)
+		for i, r := range remain {
+			if r > 0 {
+				cycle = append(cycle, d.nodes[i].id)
+			}
+		}
+		sort.Strings(cycle)
+
+		return nil, nil, fmt.Errorf("dependency cycle detected, tasks: %s", strings.Join(cycle, ", "))
+	}
+
+	return
+}
+
+/*
+Make task for worker, results of dependencies are injected if is needed.
+*/
+func (d *EasyDAG) task(i int, results []Result[[]any]) msg {
+	node := d.nodes[i]
+
+	params := node.params
+	if node.inject {
+		params = append([]any(nil), node.params...)
+		for _, dep := range node.deps {
+			params = append(params, results[d.index[dep]].Value...)
+		}
+	}
+
+	return msg{id: i, msgType: iTASK, data: params}
+}
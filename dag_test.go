@@ -0,0 +1,124 @@
+package easyworker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDAGIncorrect(t *testing.T) {
+	if _, err := NewDAG(Config{}); err == nil {
+		t.Error("missed checking config")
+	}
+
+	dag, _ := NewDAG(defaultConfig(add))
+	if _, err := dag.Run(context.Background()); err == nil {
+		t.Error("dag run without task")
+	}
+
+	dag.AddTask("a", nil, 1, 2)
+	if err := dag.AddTask("a", nil, 1, 2); err == nil {
+		t.Error("missed checking duplicated id")
+	}
+
+	dag.AddTask("b", []string{"c"}, 1, 2)
+	if _, err := dag.Run(context.Background()); err == nil {
+		t.Error("missed checking unknown dependency")
+	}
+}
+
+func TestDAGCycle(t *testing.T) {
+	dag, _ := NewDAG(defaultConfig(add))
+
+	dag.AddTask("a", nil, 1, 2)
+	dag.AddTask("b", []string{"a", "d"}, 1, 2)
+	dag.AddTask("c", []string{"b"}, 1, 2)
+	dag.AddTask("d", []string{"c"}, 1, 2)
+
+	_, err := dag.Run(context.Background())
+	if err == nil || err.Error() != "dependency cycle detected, tasks: b, c, d" {
+		t.Error("missed checking cycle", err)
+	}
+}
+
+func TestDAG(t *testing.T) {
+	config, _ := NewConfig(add, 3, 0, 0)
+	dag, _ := NewDAG(config)
+
+	// d = (a + b) + c
+	dag.AddTaskInject("d", []string{"ab", "c"})
+	dag.AddTaskInject("ab", []string{"a", "b"})
+	dag.AddTask("a", nil, 1, 2)
+	dag.AddTask("b", nil, 3, 4)
+	dag.AddTask("c", nil, 5, 6)
+
+	results, err := dag.Run(context.Background())
+	if err != nil {
+		t.Error("run dag failed, ", err)
+		return
+	}
+
+	expected := map[string]int{"a": 3, "b": 7, "c": 11, "ab": 10, "d": 21}
+	for id, v := range expected {
+		r := results[dag.IndexOf(id)]
+		if r.Err != nil || r.Value[0] != v {
+			t.Error("incorrect result of task", id, r)
+		}
+	}
+}
+
+func TestDAGDependencyFailed(t *testing.T) {
+	dag, _ := NewDAG(defaultConfig(addWithPanic))
+
+	dag.AddTask("a", nil, 3, 1)
+	dag.AddTask("b", []string{"a"}, 1, 1)
+	dag.AddTask("c", []string{"b"}, 1, 1)
+	dag.AddTask("d", nil, 1, 1)
+
+	results, err := dag.Run(context.Background())
+	if err != nil {
+		t.Error("run dag failed, ", err)
+		return
+	}
+
+	if results[0].Err == nil || errors.Is(results[0].Err, ErrDependencyFailed) {
+		t.Error("incorrect result of failed task", results[0])
+	}
+
+	for _, r := range results[1:3] {
+		if !errors.Is(r.Err, ErrDependencyFailed) || r.Attempts != 0 {
+			t.Error("dependent of failed task isn't skipped", r)
+		}
+	}
+
+	if results[3].Err != nil || results[3].Value[0] != 2 {
+		t.Error("independent task is affected", results[3])
+	}
+}
+
+func TestDAGCancel(t *testing.T) {
+	slow := func(a int) int {
+		time.Sleep(100 * time.Millisecond)
+		return a
+	}
+
+	config, _ := NewConfig(slow, 1, 0, 0)
+	dag, _ := NewDAG(config)
+
+	dag.AddTask("a", nil, 1)
+	dag.AddTask("b", []string{"a"}, 2)
+	dag.AddTask("c", nil, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	results, err := dag.Run(ctx)
+	if !errors.Is(err, ErrCancelled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("incorrect error of cancelled run,", err)
+	}
+
+	if len(results) != 3 || !errors.Is(results[1].Err, ErrCancelled) {
+		t.Error("task without result isn't marked as cancelled", results)
+	}
+}
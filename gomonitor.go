@@ -1,55 +1,61 @@
 package easyworker
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
-const (
-	// call user function success.
-	SIGNAL_DONE = iota
-
-	// call user function failed/panic
-	SIGNAL_FAILED
-)
+/*
+Options for Go, used by NewGoWithOptions.
+Zero value is a valid options (buffer is 1, drop oldest signal).
+*/
+type GoOptions struct {
+	// Buffer size of each monitor channel. Value less than 1 is treated as 1.
+	MonitorBuffer int
 
-const ()
+	// Policy when a monitor channel is full (OVERFLOW_DROP_OLDEST, OVERFLOW_DROP_NEWEST).
+	Overflow int
 
-// Signal was sent when Goroutine is no longer run (done/failed/panic).
-type GoSignal struct {
-	// Monitor reference id.
-	RefId int64
+	// If is set, Go passes a context as the first parameter of user function.
+	// Context is cancelled when Go is stopped or cancelled by a linked process.
+	Context context.Context
 
-	// Kind of signal (SIGNAL_DONE, SIGNAL_FAILED)
-	Signal int
+	// Retry policy if user function failed. Monitors receive only one signal with the final outcome.
+	Retry RetryPolicy
 }
 
-// channel for send signal.
-type monitorChan chan GoSignal
-
 // A struct wrap goroutine to handle panic and can re-run easily.
 type Go struct {
+	links
+	monitors
+
 	lock sync.Mutex
 
-	state          atomic.Int64
-	id             int64
-	panicListeners map[int64]monitorChan
+	state atomic.Int64
+	id    int64
 
 	fun    any
 	params []any
 
-	// store result
-	result []any
-}
+	opts GoOptions
 
-var (
-	lastRefId atomic.Int64
-)
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// closed for ending schedule of Go.
+	scheduleStop chan struct{}
+
+	// closed when Go is stopped (or cancelled by a linked process), interrupts waiting for retry.
+	stopped  chan struct{}
+	stopOnce sync.Once
 
-func getNewRefId() int64 {
-	return lastRefId.Add(1)
+	// store result
+	result []any
 }
 
 /*
@@ -58,18 +64,52 @@ The first parameter is user function.
 The second parameter and more is parameter for parameter of user function.
 */
 func NewGo(fun any, params ...any) (ret *Go, retErr error) {
+	return NewGoWithOptions(GoOptions{}, fun, params...)
+}
+
+/*
+Create new Go struct with options.
+Same with function NewGo but user can set options for Go.
+*/
+func NewGoWithOptions(opts GoOptions, fun any, params ...any) (ret *Go, retErr error) {
 	if retErr = verifyFunc(fun); retErr != nil {
 		return
 	}
 
+	if opts.Overflow < OVERFLOW_DROP_OLDEST || opts.Overflow > OVERFLOW_DROP_NEWEST {
+		retErr = fmt.Errorf("incorrect overflow policy, input: %d", opts.Overflow)
+		return
+	}
+
+	if retErr = opts.Retry.validate(); retErr != nil {
+		return
+	}
+
+	if opts.MonitorBuffer < 1 {
+		opts.MonitorBuffer = 1
+	}
+
 	id := getNewRefId()
 
 	ret = &Go{
-		id:             id,
-		fun:            fun,
-		params:         params,
-		panicListeners: make(map[int64]monitorChan),
+		id:      id,
+		fun:     fun,
+		params:  params,
+		opts:    opts,
+		stopped: make(chan struct{}),
 	}
+	ret.monitors.buffer = opts.MonitorBuffer
+	ret.monitors.overflow = opts.Overflow
+
+	if opts.Context != nil {
+		// add context to first param of task
+		ret.ctx, ret.cancel = context.WithCancel(opts.Context)
+		paramsWithCtx := make([]any, len(params)+1)
+		paramsWithCtx[0] = ret.ctx
+		copy(paramsWithCtx[1:], params)
+		ret.params = paramsWithCtx
+	}
+
 	ret.state.Store(STANDBY)
 
 	return
@@ -90,38 +130,6 @@ func NewGoAndRun(fun any, params ...any) (ret *Go, retErr error) {
 	return
 }
 
-/*
-Used for receiving a signal when Go done or failed.
-Function return unique reference id and a channel for receiving signal.
-*/
-func (g *Go) Monitor() (int64, <-chan GoSignal) {
-	refId := getNewRefId()
-	// always set buffer to 1 for async and run_task can exit.
-	ch := make(monitorChan, 1)
-
-	g.lock.Lock()
-	defer g.lock.Unlock()
-
-	g.panicListeners[refId] = ch
-
-	return refId, ch
-}
-
-/*
-Remove a monitor reference.
-After demonitor channel will be closed.
-*/
-func (g *Go) Demonitor(refId int64) {
-	g.lock.Lock()
-	defer g.lock.Unlock()
-
-	ch, existed := g.panicListeners[refId]
-	if existed {
-		close(ch)
-		delete(g.panicListeners, refId)
-	}
-}
-
 /*
 Run and wait for task done.
 Return true if task done in normally. False for failed. Error is cannot run task.
@@ -139,31 +147,74 @@ func (g *Go) RunAndWait() (bool, error) {
 	return msg.Signal == SIGNAL_DONE, nil
 }
 
-func (g *Go) pushSignal(msg GoSignal) {
+/*
+Stop Go just for clean data in internal struct.
+Call Stop after Go process task done.
+Schedule of Go is ended and context (if has) is cancelled.
+*/
+func (g *Go) Stop() error {
+	g.closeAll()
+
 	g.lock.Lock()
 	defer g.lock.Unlock()
 
-	for refId, ch := range g.panicListeners {
-		msg.RefId = refId
-		ch <- msg
+	g.result = nil
+	g.state.Store(STOPPED)
+	g.markStopped()
+
+	if g.scheduleStop != nil {
+		close(g.scheduleStop)
+		g.scheduleStop = nil
+	}
+
+	if g.cancel != nil {
+		g.cancel()
 	}
+
+	return nil
 }
 
 /*
-Stop Go just for clean data in internal struct.
-Call Stop after Go process task done.
+Link Go with other process (Go, Child).
+If one of them failed, the other will be cancelled unless it traps exits.
 */
-func (g *Go) Stop() {
-	g.lock.Lock()
-	defer g.lock.Unlock()
+func (g *Go) Link(other Linkable) error {
+	return Link(g, other)
+}
+
+/*
+Remove link between Go and other process.
+*/
+func (g *Go) Unlink(other Linkable) {
+	Unlink(g, other)
+}
 
-	for refId, ch := range g.panicListeners {
-		close(ch)
-		delete(g.panicListeners, refId)
+func (g *Go) getLinks() *links {
+	return &g.links
+}
+
+/*
+Cancel Go because a linked process failed.
+Context of Go is cancelled and Go cannot run again.
+*/
+func (g *Go) kill(reason any) {
+	if printLog {
+		log.Println(g.id, "Go is cancelled, reason:", reason)
 	}
 
-	g.result = nil
 	g.state.Store(STOPPED)
+	g.markStopped()
+
+	if g.cancel != nil {
+		g.cancel()
+	}
+}
+
+// close stopped channel once.
+func (g *Go) markStopped() {
+	g.stopOnce.Do(func() {
+		close(g.stopped)
+	})
 }
 
 /*
@@ -175,35 +226,86 @@ func (g *Go) Run() error {
 		return errors.New("Go cannot run, it stopped")
 	}
 
+	g.begin()
 	go g.run_task()
 	return nil
 }
 
+/*
+Start Go, same with Run. Used for Runnable interface.
+*/
+func (g *Go) Start() error {
+	return g.Run()
+}
+
+/*
+Run task, begin must be called before.
+*/
 func (g *Go) run_task() {
 	g.state.Store(RUNNING)
 	msg := GoSignal{}
+
+	var (
+		err    error
+		result []any
+	)
+
 	defer func() {
+		var reason any = err
+
 		// catch if panic by child code.
 		if r := recover(); r != nil {
 			msg.Signal = SIGNAL_FAILED
+			reason = r
 			if printLog {
 				log.Println(g.id, ", Go was panic, ", r)
 			}
 		}
 
-		g.pushSignal(msg)
-		g.state.Store(STANDBY)
+		var lastErr error
+		if msg.Signal == SIGNAL_FAILED {
+			lastErr = err
+			if lastErr == nil {
+				lastErr = fmt.Errorf("Go was panic, %v", reason)
+			}
+		}
+
+		// keep STOPPED state if Go was stopped/cancelled while running.
+		g.state.CompareAndSwap(RUNNING, STANDBY)
+
+		g.end(msg, lastErr)
+
+		propagateExit(g, msg.Signal, reason)
 	}()
 
+	//log.Println("Go run, params:", g.params)
+
 	var (
-		err    error
-		result []any
+		retry = g.opts.Retry
+		start = time.Now()
+		delay time.Duration
 	)
 
-	//log.Println("Go run, params:", g.params)
+	for {
+		msg.Attempts++
+
+		// call user define function.
+		result, err = invokeFun(g.fun, g.params...)
+
+		if err == nil {
+			break
+		}
 
-	// call user define function.
-	result, err = invokeFun(g.fun, g.params...)
+		d, ok := retry.next(msg.Attempts, delay, start, err)
+		if !ok || !g.waitRetry(d) {
+			break
+		}
+		delay = d
+
+		if printLog {
+			log.Println(g.id, "Go retry(", msg.Attempts, ") user function, last error:", err)
+		}
+	}
 
 	g.lock.Lock()
 	if err != nil {
@@ -221,6 +323,30 @@ func (g *Go) run_task() {
 	}
 }
 
+/*
+Wait before retry.
+Return false if Go was stopped or cancelled while waiting.
+*/
+func (g *Go) waitRetry(d time.Duration) bool {
+	var ctxDone <-chan struct{}
+	if g.ctx != nil {
+		ctxDone = g.ctx.Done()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctxDone:
+		return false
+	case <-g.stopped:
+		return false
+	}
+
+	return g.state.Load() != STOPPED
+}
+
 /*
 Return state of Go.
 Kind of state:
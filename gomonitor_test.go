@@ -386,3 +386,121 @@ func TestGoRerun(t *testing.T) {
 		}
 	}
 }
+
+func TestGoMonitorOverflow(t *testing.T) {
+	for _, policy := range []int{OVERFLOW_DROP_OLDEST, OVERFLOW_DROP_NEWEST} {
+		g, err := NewGoWithOptions(GoOptions{Overflow: policy}, loopRun2, 5)
+
+		if err != nil {
+			t.Error("create go failed, ", err)
+			return
+		}
+
+		// monitor is never drained.
+		_, ch := g.Monitor()
+		_, waitCh := g.Monitor()
+
+		for i := 0; i < 3; i++ {
+			g.Run()
+
+			select {
+			case sig := <-waitCh:
+				if sig.Signal != SIGNAL_DONE {
+					t.Error("task failed")
+					return
+				}
+			case <-time.After(time.Second):
+				t.Error("Go was blocked by full monitor channel")
+				return
+			}
+		}
+
+		if len(ch) != 1 || g.Dropped() != 2 {
+			t.Error("incorrect dropped signals, policy:", policy, "pending:", len(ch), "dropped:", g.Dropped())
+		}
+	}
+}
+
+func TestGoIncorrectOverflow(t *testing.T) {
+	_, err := NewGoWithOptions(GoOptions{Overflow: 100}, loopRun2, 5)
+
+	if err == nil {
+		t.Error("missed checking overflow policy")
+	}
+}
+
+func TestGoRetry(t *testing.T) {
+	counter := 0
+	failTwice := func() int {
+		counter++
+		if counter < 3 {
+			panic("test retry")
+		}
+		return counter
+	}
+
+	opts := GoOptions{Retry: RetryPolicy{MaxAttempts: 5, Backoff: BACKOFF_EXPONENTIAL, Delay: time.Millisecond}}
+	g, err := NewGoWithOptions(opts, failTwice)
+	if err != nil {
+		t.Error("create go failed, ", err)
+		return
+	}
+
+	_, ch := g.Monitor()
+	g.Run()
+
+	select {
+	case sig := <-ch:
+		if sig.Signal != SIGNAL_DONE || sig.Attempts != 3 {
+			t.Error("incorrect signal", sig)
+		}
+	case <-time.After(time.Second):
+		t.Error("timed out")
+		return
+	}
+
+	if len(ch) != 0 || g.GetResult()[0].(int) != 3 {
+		t.Error("monitor must receive only final outcome")
+	}
+}
+
+func TestGoRetryNotRetryable(t *testing.T) {
+	opts := GoOptions{Retry: RetryPolicy{
+		MaxAttempts: 5,
+		Retryable:   func(err error) bool { return false },
+	}}
+	g, _ := NewGoWithOptions(opts, simpleLoopWithPanic, 5)
+
+	_, ch := g.Monitor()
+	g.Run()
+
+	select {
+	case sig := <-ch:
+		if sig.Signal != SIGNAL_FAILED || sig.Attempts != 1 {
+			t.Error("incorrect signal", sig)
+		}
+	case <-time.After(time.Second):
+		t.Error("timed out")
+	}
+}
+
+func TestGoStopWhileWaitingRetry(t *testing.T) {
+	g, _ := NewGo(simpleLoop, 1)
+
+	done := make(chan bool)
+	go func() {
+		done <- g.waitRetry(10 * time.Second)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	g.Stop()
+
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("stopped Go must not retry")
+		}
+	case <-time.After(time.Second):
+		t.Error("waiting for retry isn't interrupted by Stop")
+	}
+}
@@ -0,0 +1,201 @@
+package easyworker
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+const (
+	// buffer size of channel for receiving exit signals.
+	iEXIT_BUFFER = 16
+)
+
+/*
+Signal was sent to a process that traps exits when a linked process exited.
+*/
+type ExitSignal struct {
+	// Process was exited.
+	From Linkable
+
+	// Kind of exit (SIGNAL_DONE, SIGNAL_FAILED)
+	Signal int
+
+	// Reason of exit, an error or a panic value. nil if process done normally.
+	Reason any
+}
+
+/*
+A process can be linked with other processes (Go, Child).
+If a process failed, linked processes will be cancelled or receive an exit signal if they trap exits.
+*/
+type Linkable interface {
+	getLinks() *links
+
+	// cancel process because a linked process failed.
+	kill(reason any)
+}
+
+// store links & trap exit option of a process.
+type links struct {
+	lock     sync.Mutex
+	linked   map[Linkable]struct{}
+	trapExit bool
+	exitCh   chan ExitSignal
+}
+
+/*
+Link two processes together. Link is bidirectional.
+If a process failed, the other will be cancelled unless it traps exits.
+Link is removed after a process failed or was cancelled.
+*/
+func Link(a, b Linkable) error {
+	if a == nil || b == nil {
+		return errors.New("cannot link a nil process")
+	}
+
+	if a == b {
+		return errors.New("cannot link a process to itself")
+	}
+
+	a.getLinks().add(b)
+	b.getLinks().add(a)
+
+	return nil
+}
+
+/*
+Remove link between two processes.
+*/
+func Unlink(a, b Linkable) {
+	if a == nil || b == nil {
+		return
+	}
+
+	a.getLinks().remove(b)
+	b.getLinks().remove(a)
+}
+
+/*
+Enable/disable trap exits.
+If enable, exit of linked processes is sent to channel from Exits instead of cancelling the process.
+*/
+func (l *links) TrapExit(enable bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.trapExit = enable
+	if l.exitCh == nil {
+		l.exitCh = make(chan ExitSignal, iEXIT_BUFFER)
+	}
+}
+
+/*
+Return channel for receiving exit signals from linked processes.
+Signals are only sent if process traps exits.
+*/
+func (l *links) Exits() <-chan ExitSignal {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.exitCh == nil {
+		l.exitCh = make(chan ExitSignal, iEXIT_BUFFER)
+	}
+
+	return l.exitCh
+}
+
+func (l *links) add(other Linkable) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.linked == nil {
+		l.linked = make(map[Linkable]struct{})
+	}
+	l.linked[other] = struct{}{}
+}
+
+func (l *links) remove(other Linkable) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.linked, other)
+}
+
+func (l *links) list() []Linkable {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	ret := make([]Linkable, 0, len(l.linked))
+	for other := range l.linked {
+		ret = append(ret, other)
+	}
+
+	return ret
+}
+
+// remove all links of process and return linked processes.
+func (l *links) takeAll() []Linkable {
+	l.lock.Lock()
+	ret := make([]Linkable, 0, len(l.linked))
+	for other := range l.linked {
+		ret = append(ret, other)
+	}
+	l.linked = nil
+	l.lock.Unlock()
+
+	return ret
+}
+
+/*
+Handle exit of a linked process.
+Trap exits process receives a signal, other process is cancelled if linked process failed.
+*/
+func (l *links) onExit(self Linkable, sig ExitSignal) {
+	l.lock.Lock()
+	trapExit := l.trapExit
+	ch := l.exitCh
+	l.lock.Unlock()
+
+	if trapExit {
+		select {
+		case ch <- sig:
+		default:
+			if printLog {
+				log.Println("exit channel is full, exit signal was dropped")
+			}
+		}
+		return
+	}
+
+	if sig.Signal == SIGNAL_DONE {
+		return
+	}
+
+	reason := fmt.Errorf("linked process exited, reason: %v", sig.Reason)
+	self.kill(reason)
+
+	propagateExit(self, SIGNAL_FAILED, reason)
+}
+
+/*
+Send exit of process to linked processes.
+Links are kept if process done normally, otherwise links are removed.
+*/
+func propagateExit(from Linkable, signal int, reason any) {
+	var peers []Linkable
+
+	if signal == SIGNAL_DONE {
+		peers = from.getLinks().list()
+	} else {
+		peers = from.getLinks().takeAll()
+		for _, peer := range peers {
+			peer.getLinks().remove(from)
+		}
+	}
+
+	for _, peer := range peers {
+		peer.getLinks().onExit(peer, ExitSignal{From: from, Signal: signal, Reason: reason})
+	}
+}
@@ -0,0 +1,108 @@
+package easyworker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func waitForCancel(ctx context.Context) {
+	<-ctx.Done()
+}
+
+func TestLinkSelf(t *testing.T) {
+	g, _ := NewGo(loopRun2, 5)
+
+	if err := g.Link(g); err == nil {
+		t.Error("missed checking link to itself")
+	}
+}
+
+func TestLinkCancel(t *testing.T) {
+	owner, _ := NewGo(simpleLoopWithPanic, 5)
+	helper, _ := NewGoWithOptions(GoOptions{Context: context.Background()}, waitForCancel)
+
+	owner.Link(helper)
+
+	_, ch := helper.Monitor()
+	helper.Run()
+	owner.Run()
+
+	select {
+	case <-ch:
+		if helper.State() != STOPPED {
+			t.Error("linked Go isn't stopped, state:", helper.State())
+		}
+	case <-time.After(time.Second):
+		t.Error("linked Go isn't cancelled")
+	}
+
+	if err := helper.Run(); err == nil {
+		t.Error("cancelled Go can run again")
+	}
+}
+
+func TestLinkDoneNotCancel(t *testing.T) {
+	owner, _ := NewGo(loopRun2, 5)
+	helper, _ := NewGoWithOptions(GoOptions{Context: context.Background()}, waitForCancel)
+
+	owner.Link(helper)
+
+	helper.Run()
+	owner.RunAndWait()
+
+	time.Sleep(10 * time.Millisecond)
+
+	if helper.State() != RUNNING {
+		t.Error("linked Go is cancelled by normal exit")
+	}
+
+	helper.Stop()
+}
+
+func TestLinkTrapExit(t *testing.T) {
+	owner, _ := NewGo(simpleLoopWithPanic, 5)
+	helper, _ := NewGoWithOptions(GoOptions{Context: context.Background()}, waitForCancel)
+
+	helper.TrapExit(true)
+	helper.Link(owner)
+
+	helper.Run()
+	owner.Run()
+
+	select {
+	case sig := <-helper.Exits():
+		if sig.From != owner || sig.Signal != SIGNAL_FAILED || sig.Reason == nil {
+			t.Error("incorrect exit signal", sig)
+		}
+	case <-time.After(time.Second):
+		t.Error("timed out")
+	}
+
+	if helper.State() != RUNNING {
+		t.Error("trap exits Go is cancelled")
+	}
+
+	helper.Stop()
+}
+
+func TestLinkChild(t *testing.T) {
+	sup := NewSupervisorWithContext(context.Background())
+
+	owner, _ := NewGo(simpleLoopWithPanic, 5)
+	child, _ := NewChild(ALWAYS_RESTART, waitForCancel)
+
+	owner.Link(child)
+	sup.AddChild(child)
+
+	owner.Run()
+
+	time.Sleep(50 * time.Millisecond)
+
+	if state, _, _ := child.GetStats(); state != STOPPED {
+		t.Error("linked child isn't stopped, state:", state)
+	}
+
+	sup.Stop()
+	RemoveSupervisor(&sup)
+}
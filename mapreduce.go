@@ -0,0 +1,118 @@
+package easyworker
+
+import (
+	"context"
+	"errors"
+)
+
+/*
+Run mapper for each input on a worker pool and fold results by reducer as they arrive.
+Results aren't stored, reducer is called in completion order (in goroutine of caller),
+so reducer should be commutative & associative if result must not depend on order.
+Return the final reduced value and failed tasks (with error wraps ErrCancelled if context was cancelled).
+In fail fast mode, error of the first failed task is returned with partial reduced value.
+
+Example:
+
+	config, _ := NewPoolConfig(3, 0, 0)
+
+	total, failures, err := MapReduce(context.Background(), config, []string{"a.txt", "b.txt"},
+		func(ctx context.Context, file string) (int, error) {
+			return countLines(file)
+		},
+		func(acc int, lines int) int {
+			return acc + lines
+		}, 0)
+*/
+func MapReduce[In, Mid, Out any](ctx context.Context, config Config, inputs []In,
+	mapper func(context.Context, In) (Mid, error), reducer func(acc Out, value Mid) Out, initial Out) (ret Out, failures []Result[Mid], retErr error) {
+	ret = initial
+
+	if reducer == nil {
+		retErr = errors.New("reducer is nil")
+		return
+	}
+
+	task, err := NewTaskOf(mapper, config)
+	if err != nil {
+		retErr = err
+		return
+	}
+
+	if len(inputs) < 1 {
+		retErr = errors.New("need inputs to run")
+		return
+	}
+	task.inputs = inputs
+
+	retErr = task.each(ctx, func(result Result[Mid]) {
+		if result.Err != nil {
+			failures = append(failures, result)
+			return
+		}
+		ret = reducer(ret, result.Value)
+	})
+
+	return
+}
+
+/*
+Run tasks of EasyTask and fold results by reducer as they arrive, same with MapReduce but mapper is function in config.
+Results aren't stored for GetResult. Monitors receive signal after run done, same with Run.
+
+Example:
+
+	sum, failures, err := ReduceTask(context.Background(), &easyTask, func(acc int, values []any) int {
+		return acc + values[0].(int)
+	}, 0)
+*/
+func ReduceTask[Out any](ctx context.Context, task *EasyTask, reducer func(acc Out, values []any) Out, initial Out) (ret Out, failures []Result[[]any], retErr error) {
+	ret = initial
+
+	if task == nil || reducer == nil {
+		retErr = errors.New("task or reducer is nil")
+		return
+	}
+
+	if ctx == nil {
+		retErr = errors.New("context is nil")
+		return
+	}
+
+	if !task.state.CompareAndSwap(STANDBY, RUNNING) {
+		retErr = errors.New("EasyTask is running or stopped")
+		return
+	}
+	task.begin()
+
+	// error of the first failed task in fail fast mode.
+	var failErr error
+
+	ch, err := task.stream(ctx, func(err error) {
+		failErr = err
+	})
+	if err != nil {
+		task.finish(nil, nil, err)
+		retErr = err
+		return
+	}
+
+	defer func() {
+		// drain results if reducer was panic, workers are stopped after that.
+		for range ch {
+		}
+		task.finish(nil, nil, retErr)
+	}()
+
+	for result := range ch {
+		if result.Err != nil {
+			failures = append(failures, result)
+			continue
+		}
+		ret = reducer(ret, result.Value)
+	}
+
+	retErr = failErr
+
+	return
+}
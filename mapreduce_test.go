@@ -0,0 +1,68 @@
+package easyworker
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMapReduce(t *testing.T) {
+	config, _ := NewPoolConfig(3, 0, 0)
+
+	inputs := []int{-1, 1, 2, 3, -2}
+
+	sum, failures, err := MapReduce(context.Background(), config, inputs, square, func(acc int, v int) int {
+		return acc + v
+	}, 0)
+
+	if err != nil {
+		t.Error("map reduce failed, ", err)
+		return
+	}
+
+	if sum != 14 {
+		t.Error("incorrect reduced value", sum)
+	}
+
+	if len(failures) != 2 || failures[0].Err == nil {
+		t.Error("incorrect failures", failures)
+	}
+}
+
+func TestMapReduceIncorrect(t *testing.T) {
+	config, _ := NewPoolConfig(1, 0, 0)
+
+	if _, _, err := MapReduce[int, int, int](context.Background(), config, []int{1}, square, nil, 0); err == nil {
+		t.Error("missed checking nil reducer")
+	}
+
+	if _, _, err := MapReduce(context.Background(), config, nil, square, func(acc, v int) int { return acc }, 0); err == nil {
+		t.Error("map reduce without input")
+	}
+}
+
+func TestReduceTask(t *testing.T) {
+	config, _ := NewConfig(addWithPanic, 2, 0, 0)
+	eWorker, _ := NewTask(config)
+
+	for i := 1; i <= 4; i++ {
+		eWorker.AddTask(i, i)
+	}
+
+	sum, failures, err := ReduceTask(context.Background(), &eWorker, func(acc int, values []any) int {
+		return acc + values[0].(int)
+	}, 0)
+
+	if err != nil {
+		t.Error("reduce task failed, ", err)
+		return
+	}
+
+	// 3 is panic.
+	if sum != 14 || len(failures) != 1 || failures[0].Index != 2 {
+		t.Error("incorrect reduced result", sum, failures)
+	}
+
+	if eWorker.State() != STANDBY || eWorker.Wait() != nil {
+		t.Error("incorrect state after reduce", eWorker.State())
+	}
+}
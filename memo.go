@@ -0,0 +1,186 @@
+package easyworker
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// Result was computed by user function.
+	RESULT_COMPUTED = iota
+
+	// Result was shared from a running task has same key (deduplicated).
+	RESULT_SHARED
+
+	// Result was served from cache of recent results.
+	RESULT_CACHED
+)
+
+// options of memoization.
+type memoOptions struct {
+	key func(args []any) any
+
+	// time to live & maximum number of cached results, 0 ttl is no cache.
+	ttl  time.Duration
+	size int
+}
+
+/*
+Enable memoization for EasyStream, key is derived from params of task by key function (key must be comparable).
+Tasks have same key with a running task aren't run, they share result of the running task (singleflight).
+If ttl > 0, successful results are cached for ttl (maximum maxSize results, the least recently used is evicted),
+tasks have a cached key get the cached result immediately. Failed results aren't cached.
+Source of Result (NewStreamResult) is RESULT_COMPUTED, RESULT_SHARED or RESULT_CACHED.
+
+Example:
+
+	config.SetMemoize(func(args []any) any {
+		// first param is id.
+		return args[0]
+	}, 10*time.Second, 1000)
+*/
+func (c *Config) SetMemoize(key func(args []any) any, ttl time.Duration, maxSize int) error {
+	if key == nil {
+		return errors.New("key function is nil")
+	}
+
+	if ttl < 0 {
+		return fmt.Errorf("ttl of cache is incorrect, %s", ttl)
+	}
+
+	if ttl > 0 && maxSize < 1 {
+		return fmt.Errorf("size of cache is incorrect, %d", maxSize)
+	}
+
+	c.memo = &memoOptions{
+		key:  key,
+		ttl:  ttl,
+		size: maxSize,
+	}
+
+	return nil
+}
+
+// cached result of a key.
+type memoEntry struct {
+	key    any
+	value  any
+	expire time.Time
+}
+
+/*
+Memoization of a stream run, shared by dispatcher & result loop.
+*/
+type memo struct {
+	memoOptions
+
+	lock sync.Mutex
+
+	// key of running tasks (by id of task).
+	keys map[int]any
+
+	// duplicated tasks are waiting for result of running task (by key).
+	waiters map[any][]msg
+
+	// cached results, the most recently used is in front.
+	lru   *list.List
+	cache map[any]*list.Element
+}
+
+func newMemo(opts *memoOptions) *memo {
+	return &memo{
+		memoOptions: *opts,
+		keys:        make(map[int]any),
+		waiters:     make(map[any][]msg),
+		lru:         list.New(),
+		cache:       make(map[any]*list.Element),
+	}
+}
+
+/*
+Check a new task before queueing.
+Return cached value (true) if key has a cached result.
+Return (nil, false, true) if a task has same key is running, task is waiting for result of that task.
+Otherwise, task is run & its key is tracked.
+*/
+func (m *memo) begin(task msg) (value any, cached bool, shared bool) {
+	key := m.key(task.data.([]any))
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if e, existed := m.cache[key]; existed {
+		entry := e.Value.(*memoEntry)
+		if time.Now().Before(entry.expire) {
+			m.lru.MoveToFront(e)
+			return entry.value, true, false
+		}
+		m.lru.Remove(e)
+		delete(m.cache, key)
+	}
+
+	if waiters, existed := m.waiters[key]; existed {
+		m.waiters[key] = append(waiters, task)
+		return nil, false, true
+	}
+
+	m.keys[task.id] = key
+	m.waiters[key] = nil
+
+	return nil, false, false
+}
+
+/*
+Receive result of a task, successful result is cached.
+Return results of duplicated tasks were waiting for result.
+*/
+func (m *memo) done(result msg) []msg {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	key, existed := m.keys[result.id]
+	if !existed {
+		return nil
+	}
+	delete(m.keys, result.id)
+
+	waiters := m.waiters[key]
+	delete(m.waiters, key)
+
+	if result.msgType == iSUCCESS && m.ttl > 0 {
+		m.store(key, result.data)
+	}
+
+	ret := make([]msg, len(waiters))
+	for i, task := range waiters {
+		r := result
+		r.id = task.id
+		r.input = task.data
+		r.source = RESULT_SHARED
+		ret[i] = r
+	}
+
+	return ret
+}
+
+// add result to cache, the least recently used result is evicted if cache is full.
+func (m *memo) store(key any, value any) {
+	entry := &memoEntry{key: key, value: value, expire: time.Now().Add(m.ttl)}
+
+	if e, existed := m.cache[key]; existed {
+		e.Value = entry
+		m.lru.MoveToFront(e)
+		return
+	}
+
+	m.cache[key] = m.lru.PushFront(entry)
+
+	for m.lru.Len() > m.size {
+		e := m.lru.Back()
+		m.lru.Remove(e)
+		delete(m.cache, e.Value.(*memoEntry).key)
+	}
+}
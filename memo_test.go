@@ -0,0 +1,134 @@
+package easyworker
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func firstArg(args []any) any {
+	return args[0]
+}
+
+func TestIncorrectMemoize(t *testing.T) {
+	config := defaultConfig(add)
+
+	if err := config.SetMemoize(nil, time.Second, 10); err == nil {
+		t.Error("missed checking key function")
+	}
+
+	if err := config.SetMemoize(firstArg, -time.Second, 10); err == nil {
+		t.Error("missed checking ttl")
+	}
+
+	if err := config.SetMemoize(firstArg, time.Second, 0); err == nil {
+		t.Error("missed checking size of cache")
+	}
+
+	// only deduplication, no cache.
+	if err := config.SetMemoize(firstArg, 0, 0); err != nil {
+		t.Error("deduplication without cache is valid,", err)
+	}
+}
+
+func TestMemoCache(t *testing.T) {
+	m := newMemo(&memoOptions{key: firstArg, ttl: 50 * time.Millisecond, size: 1})
+
+	for i, key := range []string{"a", "b"} {
+		task := msg{id: i, msgType: iTASK, data: []any{key}}
+		if _, cached, shared := m.begin(task); cached || shared {
+			t.Error("new key must be run", key)
+		}
+		m.done(msg{id: i, msgType: iSUCCESS, data: []any{key}})
+	}
+
+	// "a" was evicted.
+	if _, cached, _ := m.begin(msg{id: 2, data: []any{"a"}}); cached {
+		t.Error("evicted key is cached")
+	}
+
+	if v, cached, _ := m.begin(msg{id: 3, data: []any{"b"}}); !cached || v.([]any)[0] != "b" {
+		t.Error("incorrect cached result", v)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, cached, _ := m.begin(msg{id: 4, data: []any{"b"}}); cached {
+		t.Error("expired key is cached")
+	}
+
+	// failed result isn't cached.
+	m.done(msg{id: 4, msgType: iERROR})
+	if _, cached, _ := m.begin(msg{id: 5, data: []any{"b"}}); cached {
+		t.Error("failed result is cached")
+	}
+}
+
+func TestStreamMemoize(t *testing.T) {
+	var calls atomic.Int64
+
+	fn := func(id string) string {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return id + "!"
+	}
+
+	inCh := make(chan []any)
+	outCh := make(chan Result[[]any])
+
+	config, _ := NewConfig(fn, 2, 0, 0)
+	config.SetMemoize(firstArg, time.Minute, 10)
+
+	stream, _ := NewStreamResult(config, inCh, outCh)
+	defer stream.Stop()
+
+	if err := stream.Run(); err != nil {
+		t.Error("run stream failed,", err)
+		return
+	}
+
+	receive := func() (r Result[[]any], ok bool) {
+		select {
+		case r = <-outCh:
+			return r, true
+		case <-time.After(time.Second):
+			t.Error("timed out")
+			return r, false
+		}
+	}
+
+	go func() {
+		for _, id := range []string{"a", "a", "b", "a"} {
+			inCh <- []any{id}
+		}
+	}()
+
+	sources := make(map[int]int)
+	for i := 0; i < 4; i++ {
+		r, ok := receive()
+		if !ok {
+			return
+		}
+
+		if r.Err != nil || r.Value[0] != r.Args[0].(string)+"!" {
+			t.Error("incorrect result", r)
+		}
+		sources[r.Source]++
+	}
+
+	if sources[RESULT_COMPUTED] != 2 || sources[RESULT_SHARED] != 2 || calls.Load() != 2 {
+		t.Error("duplicated tasks weren't shared", sources, calls.Load())
+	}
+
+	inCh <- []any{"b"}
+	r, ok := receive()
+	if !ok {
+		return
+	}
+	if r.Source != RESULT_CACHED || r.Value[0] != "b!" || r.Index != 4 || calls.Load() != 2 {
+		t.Error("result isn't served from cache", r)
+	}
+
+	if stats := stream.Stats(); stats.Shared != 2 || stats.CacheHits != 1 {
+		t.Error("incorrect stats", stats)
+	}
+}
@@ -0,0 +1,215 @@
+package easyworker
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// call user function success.
+	SIGNAL_DONE = iota
+
+	// call user function failed/panic
+	SIGNAL_FAILED
+)
+
+const (
+	// Monitor channel is full, drop the oldest pending signal to keep the new one.
+	OVERFLOW_DROP_OLDEST = iota
+
+	// Monitor channel is full, drop the new signal.
+	OVERFLOW_DROP_NEWEST
+)
+
+// Signal was sent when a process (Go, Child, EasyTask, EasyStream) is no longer run (done/failed/panic).
+type GoSignal struct {
+	// Monitor reference id.
+	RefId int64
+
+	// Kind of signal (SIGNAL_DONE, SIGNAL_FAILED)
+	Signal int
+
+	// Number of attempts were run for the last Run.
+	Attempts int
+}
+
+// channel for send signal.
+type monitorChan chan GoSignal
+
+var (
+	lastRefId atomic.Int64
+)
+
+func getNewRefId() int64 {
+	return lastRefId.Add(1)
+}
+
+/*
+Store monitors of a process and send signal to them without blocking.
+Zero value is ready to use (buffer is 1, drop oldest signal).
+*/
+type monitors struct {
+	lock      sync.Mutex
+	listeners map[int64]monitorChan
+
+	// buffer size of monitor channel & overflow policy.
+	buffer   int
+	overflow int
+
+	// number of signals were dropped because monitor channel is full.
+	dropped atomic.Int64
+
+	// number of runs aren't done.
+	active int
+
+	// error of the last run.
+	lastErr error
+}
+
+/*
+Used for receiving a signal when process done or failed.
+Function return unique reference id and a channel for receiving signal.
+*/
+func (m *monitors) Monitor() (int64, <-chan GoSignal) {
+	refId := getNewRefId()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return refId, m.add(refId)
+}
+
+// add a monitor channel, caller must hold lock.
+func (m *monitors) add(refId int64) monitorChan {
+	buffer := m.buffer
+	if buffer < 1 {
+		buffer = 1
+	}
+
+	// channel is buffered, process never blocks when sending signal.
+	ch := make(monitorChan, buffer)
+
+	if m.listeners == nil {
+		m.listeners = make(map[int64]monitorChan)
+	}
+	m.listeners[refId] = ch
+
+	return ch
+}
+
+/*
+Remove a monitor reference.
+After demonitor channel will be closed.
+*/
+func (m *monitors) Demonitor(refId int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ch, existed := m.listeners[refId]
+	if existed {
+		close(ch)
+		delete(m.listeners, refId)
+	}
+}
+
+/*
+Return number of signals were dropped because monitor channels are full.
+*/
+func (m *monitors) Dropped() int64 {
+	return m.dropped.Load()
+}
+
+/*
+Wait for all running tasks done.
+Return error of the last run, nil if it was done normally.
+*/
+func (m *monitors) Wait() error {
+	for {
+		m.lock.Lock()
+		if m.active < 1 {
+			err := m.lastErr
+			m.lock.Unlock()
+			return err
+		}
+
+		refId := getNewRefId()
+		ch := m.add(refId)
+		m.lock.Unlock()
+
+		<-ch
+		m.Demonitor(refId)
+	}
+}
+
+// mark a run is started, must call before starting goroutine of process.
+func (m *monitors) begin() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.active++
+}
+
+// mark a run is done and send signal to monitors.
+func (m *monitors) end(msg GoSignal, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.active > 0 {
+		m.active--
+	}
+
+	if msg.Signal == SIGNAL_FAILED && err == nil {
+		err = errors.New("process failed")
+	}
+	m.lastErr = err
+
+	for refId, ch := range m.listeners {
+		msg.RefId = refId
+		m.deliver(ch, msg)
+	}
+}
+
+/*
+Send signal to a monitor channel without blocking.
+If channel is full, a signal will be dropped depend on overflow policy.
+*/
+func (m *monitors) deliver(ch monitorChan, msg GoSignal) {
+	select {
+	case ch <- msg:
+		return
+	default:
+	}
+
+	if m.overflow == OVERFLOW_DROP_OLDEST {
+		// remove the oldest signal if monitor doesn't take it yet.
+		select {
+		case <-ch:
+			m.dropped.Add(1)
+		default:
+		}
+
+		select {
+		case ch <- msg:
+			return
+		default:
+		}
+	}
+
+	m.dropped.Add(1)
+	if printLog {
+		log.Println("monitor channel is full, signal was dropped")
+	}
+}
+
+// close all monitor channels.
+func (m *monitors) closeAll() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for refId, ch := range m.listeners {
+		close(ch)
+		delete(m.listeners, refId)
+	}
+}
@@ -0,0 +1,465 @@
+package easyworker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// Error of a task was cancelled before it done, used with errors.Is.
+	ErrCancelled = errors.New("task was cancelled")
+
+	// Error of an attempt was timed out, used with errors.Is.
+	ErrTimeout = errors.New("task was timed out")
+
+	// Error of a run was stopped because all workers were dead, used with errors.Is.
+	ErrNoWorkers = errors.New("all workers were dead")
+)
+
+// function calls user function with data of a task.
+type invoker func(ctx context.Context, data any) (any, error)
+
+/*
+A pool of workers, shared by EasyTask, EasyStream & TaskOf.
+*/
+type pool struct {
+	// context of workers, cancelled when pool is stopped.
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	// stop batch at the first failed task.
+	failFast bool
+
+	// aging interval of task priority.
+	aging time.Duration
+
+	// rate limiter for dispatching tasks, nil is no limit.
+	limiter *rateLimiter
+
+	// batching mode, nil is disabled.
+	batch *batchOptions
+
+	// stats of owner.
+	stats *counters
+
+	// options of workers.
+	call    invoker
+	retry   RetryPolicy
+	timeout time.Duration
+
+	// autoscaling of workers, nil is fixed number of workers.
+	scale *autoscaleOptions
+
+	// restart strategy of dead workers (ALWAYS_RESTART, ERROR_RESTART, NO_RESTART).
+	restart int
+
+	// id of the next worker, protected by lock.
+	nextId int64
+
+	// counters of running tasks.
+	progress progressCounters
+
+	// progress callback & interval of updates.
+	onProgress       func(Progress)
+	progressInterval time.Duration
+
+	// use for send task to worker.
+	inputCh chan msg
+
+	// use for get result from worker.
+	resultCh chan msg
+
+	lock sync.Mutex
+
+	// store runtime workers.
+	workers map[int]*worker
+
+	// closed for stopping workers.
+	quit     chan msg
+	stopOnce sync.Once
+
+	// wait for all workers exited.
+	wg sync.WaitGroup
+
+	// tasks were added to running batch & batch cannot be added anymore, protected by lock.
+	added  []queuedTask
+	sealed bool
+
+	// notify batch has added tasks.
+	notify chan struct{}
+}
+
+// task of batch, with priority.
+type queuedTask struct {
+	task     msg
+	priority int
+}
+
+/*
+Start workers of pool with options in config.
+stats is shared between runs of owner.
+*/
+func startPool(ctx context.Context, config Config, call invoker, stats *counters) *pool {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	p := &pool{
+		ctx:      ctx,
+		cancel:   cancel,
+		failFast: config.failFast,
+		aging:    config.aging,
+		limiter:  config.limiter,
+		batch:    config.batch,
+		stats:    stats,
+		call:     call,
+		retry:    config.retry,
+		timeout:  config.timeout,
+		scale:    config.scale,
+		restart:  config.restart,
+
+		onProgress:       config.onProgress,
+		progressInterval: config.progressInterval,
+
+		inputCh:  make(chan msg, config.worker),
+		resultCh: make(chan msg, config.worker),
+		workers:  make(map[int]*worker, config.worker),
+		quit:     make(chan msg),
+		notify:   make(chan struct{}, 1),
+	}
+
+	n := config.worker
+	if p.scale != nil {
+		n = p.scale.initial(n)
+	}
+
+	// pool isn't shared yet.
+	for i := 0; i < n; i++ {
+		p.addWorker()
+	}
+
+	if p.scale != nil {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.autoscale()
+		}()
+	}
+
+	return p
+}
+
+/*
+Start a new worker, caller must hold lock.
+Return false if pool was stopped.
+*/
+func (p *pool) addWorker() bool {
+	if p.ctx.Err() != nil {
+		return false
+	}
+
+	w := &worker{
+		id:       p.nextId,
+		call:     p.call,
+		ctx:      p.ctx,
+		cmd:      p.quit,
+		resultCh: p.resultCh,
+		inputCh:  p.inputCh,
+		retry:    p.retry,
+		timeout:  p.timeout,
+		stats:    p.stats,
+		progress: &p.progress,
+	}
+	w.dead = p.replace
+	if p.scale != nil {
+		w.idle = p.scale.cooldown
+		w.retire = p.retire
+	}
+
+	p.workers[int(w.id)] = w
+	p.nextId++
+
+	p.stats.workers.Add(1)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer p.stats.workers.Add(-1)
+		w.run()
+	}()
+
+	return true
+}
+
+/*
+Send tasks to workers and wait for all results.
+Tasks are dispatched by priority, FIFO for same priority.
+Tasks can be added to a running batch by add until batch is sealed, batch is sealed when all tasks done.
+onResult is called for each result in completion order.
+If context is cancelled, tasks aren't dispatched anymore and tasks without result are marked cancelled.
+In fail fast mode, the first failed task cancels the batch and its error is returned.
+*/
+func (p *pool) runBatch(tasks []queuedTask, onResult func(result msg)) (retErr error) {
+	ctx := p.ctx
+
+	queue := newPriorityQueue(p.aging)
+
+	// input of tasks don't have result.
+	waiting := make(map[int]any, len(tasks))
+
+	for _, t := range tasks {
+		queue.add(t.task, t.priority)
+		waiting[t.task.id] = t.task.data
+	}
+
+	// tasks are taken from queue, waiting for rate limit or a worker.
+	th := newThrottle(p)
+	defer th.stop()
+
+	var (
+		tracker = progressTracker{start: time.Now(), total: len(waiting)}
+		ticker  <-chan time.Time
+	)
+
+	if p.onProgress != nil {
+		t := time.NewTicker(p.progressInterval)
+		defer t.Stop()
+		ticker = t.C
+
+		// the last progress.
+		defer func() {
+			p.onProgress(tracker.snapshot(&p.progress))
+		}()
+	}
+
+	// move tasks were added to queue.
+	takeAdded := func(seal bool) bool {
+		n := len(waiting)
+		added := p.takeAdded(queue, waiting, seal)
+		tracker.total += len(waiting) - n
+		return added
+	}
+
+	for {
+		if len(waiting) == 0 {
+			// seal batch if no task was added.
+			if !takeAdded(true) {
+				break
+			}
+		}
+
+		// take tasks from queue until a task is ready, tasks of throttled keys wait for token.
+		// tasks taken from queue are limited (number of workers), tasks added later with higher priority can run first.
+		for queue.Len() > 0 && th.size() < cap(p.inputCh) {
+			if _, ok := th.next(); ok {
+				break
+			}
+			th.admit(p.nextTask(queue))
+		}
+
+		var out chan msg
+		next, ok := th.next()
+		if ok {
+			out = p.inputCh
+		}
+
+		var result msg
+
+		select {
+		case out <- next:
+			th.pop()
+			p.dispatched()
+			continue
+		case <-th.wake():
+			th.release()
+			continue
+		case <-p.notify:
+			takeAdded(false)
+			continue
+		case <-ticker:
+			p.onProgress(tracker.snapshot(&p.progress))
+			continue
+		case result = <-p.resultCh:
+		case <-ctx.Done():
+			if printLog {
+				log.Println("tasks are cancelled, reason:", ctx.Err())
+			}
+
+			takeAdded(true)
+
+			ids := make([]int, 0, len(waiting))
+			for id := range waiting {
+				ids = append(ids, id)
+			}
+			sort.Ints(ids)
+
+			err := fmt.Errorf("%w, %w", ErrCancelled, context.Cause(ctx))
+			for _, id := range ids {
+				onResult(msg{id: id, msgType: iCANCEL, data: err, input: waiting[id], worker: -1})
+			}
+			tracker.failed += len(ids)
+
+			// error of fail fast mode or dead workers is kept.
+			if retErr == nil {
+				retErr = err
+			}
+			return
+		}
+
+		switch result.msgType {
+		case iSUCCESS: // task done
+		case iERROR: // task failed
+			if printLog {
+				log.Println("task", result.id, " is failed, error:", result.data)
+			}
+		case iCANCEL: // task was cancelled before running
+		case iFATAL_ERROR: // worker panic, task of worker was answered.
+			if printLog {
+				log.Println(result.id, "worker is fatal error")
+			}
+			if p.alive() == 0 && retErr == nil {
+				retErr = ErrNoWorkers
+				p.cancel(retErr)
+			}
+			continue
+		case iQUIT: // worker quited
+			if printLog {
+				log.Println(result.id, " exited")
+			}
+			continue
+		}
+
+		for _, result := range splitBatch(result) {
+			if _, existed := waiting[result.id]; !existed {
+				continue
+			}
+
+			delete(waiting, result.id)
+			onResult(result)
+
+			if result.msgType == iSUCCESS {
+				tracker.completed++
+			} else {
+				tracker.failed++
+			}
+
+			if p.failFast && result.msgType == iERROR && retErr == nil {
+				retErr, _ = result.data.(error)
+				p.cancel(fmt.Errorf("fail fast, task %d failed, reason: %v", result.id, retErr))
+			}
+		}
+	}
+
+	return
+}
+
+/*
+Add a task to running batch.
+Return false if batch was sealed, task isn't run.
+*/
+func (p *pool) add(task msg, priority int) bool {
+	p.lock.Lock()
+	if p.sealed {
+		p.lock.Unlock()
+		return false
+	}
+	p.added = append(p.added, queuedTask{task: task, priority: priority})
+	p.lock.Unlock()
+
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+
+	return true
+}
+
+/*
+Seal batch, tasks cannot be added anymore.
+*/
+func (p *pool) seal() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.sealed = true
+}
+
+/*
+Move added tasks to queue, batch is sealed if seal is true and no task was added.
+Return true if has task was added.
+*/
+func (p *pool) takeAdded(queue *priorityQueue, waiting map[int]any, seal bool) bool {
+	p.lock.Lock()
+	tasks := p.added
+	p.added = nil
+	if seal && len(tasks) == 0 {
+		p.sealed = true
+	}
+	p.lock.Unlock()
+
+	for _, t := range tasks {
+		queue.add(t.task, t.priority)
+		waiting[t.task.id] = t.task.data
+	}
+
+	return len(tasks) > 0
+}
+
+// count a task was dispatched to workers.
+func (p *pool) dispatched() {
+	p.stats.dispatched.add(time.Now())
+}
+
+/*
+Replace a dead worker by a new worker, dead worker is only removed if restart strategy is NO_RESTART.
+*/
+func (p *pool) replace(id int64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// pool was stopped or worker was retired.
+	if _, existed := p.workers[int(id)]; !existed {
+		return
+	}
+	delete(p.workers, int(id))
+
+	if p.restart == NO_RESTART || !p.addWorker() {
+		return
+	}
+
+	p.stats.restarts.Add(1)
+	if printLog {
+		log.Println("worker", id, "was dead, restarted as worker", p.nextId-1)
+	}
+}
+
+/*
+Return number of workers are alive.
+*/
+func (p *pool) alive() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return len(p.workers)
+}
+
+/*
+Stop workers, context of workers is cancelled.
+Function returns after all workers exited, running calls of user function are abandoned.
+*/
+func (p *pool) stop() {
+	p.cancel(nil)
+	p.seal()
+
+	p.stopOnce.Do(func() {
+		close(p.quit)
+	})
+
+	p.lock.Lock()
+	p.workers = make(map[int]*worker)
+	p.lock.Unlock()
+
+	p.wg.Wait()
+}
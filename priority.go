@@ -0,0 +1,136 @@
+package easyworker
+
+import (
+	"container/heap"
+	"math"
+	"time"
+)
+
+const (
+	// Default aging, priority of a waiting task is increased by 1 after each interval.
+	iDEFAULT_AGING = time.Second
+)
+
+/*
+Return maximum number of stream tasks are queued for priority, stream stops receiving tasks if queue is full.
+Queue is small (number of workers or batch size), input channel of user keeps backpressure
+and a few tasks are dropped when stream is stopped.
+*/
+func streamQueueSize(config Config) int {
+	ret := config.worker
+	if config.scale != nil && config.scale.max > ret {
+		ret = config.scale.max
+	}
+	if config.batch != nil && config.batch.size > ret {
+		ret = config.batch.size
+	}
+	return ret
+}
+
+// item of priority queue.
+type queueItem struct {
+	task msg
+
+	// priority with aging, the higher is run first.
+	key int64
+
+	// order of adding, used for tasks have same key.
+	seq int64
+
+	// time of adding, used for max wait time of batching mode.
+	added time.Time
+}
+
+/*
+Priority queue of tasks, the highest priority is dispatched first. Tasks have same priority are dispatched in FIFO.
+With aging, priority of a task is increased by 1 for each aging interval it waits, low priority tasks aren't starved.
+*/
+type priorityQueue struct {
+	items []queueItem
+
+	// aging interval, 0 is no aging.
+	aging time.Duration
+
+	// time of creating queue, waiting time of tasks is counted from it.
+	base time.Time
+
+	seq int64
+}
+
+func newPriorityQueue(aging time.Duration) *priorityQueue {
+	return &priorityQueue{
+		aging: aging,
+		base:  time.Now(),
+	}
+}
+
+/*
+Add a task to queue.
+With aging, priority at time t is priority + (t - added)/aging. Order of two tasks doesn't change by time,
+so key is priority*aging - added (relative to base of queue).
+Priority is clamped for aging, key doesn't overflow (a half of int64 is kept for waiting time).
+*/
+func (q *priorityQueue) add(task msg, priority int) {
+	key := int64(priority)
+	if q.aging > 0 {
+		limit := math.MaxInt64 / 2 / int64(q.aging)
+		if key > limit {
+			key = limit
+		} else if key < -limit {
+			key = -limit
+		}
+		key = key*int64(q.aging) - int64(time.Since(q.base))
+	}
+
+	q.seq++
+	heap.Push(q, queueItem{task: task, key: key, seq: q.seq, added: time.Now()})
+}
+
+// return task has the highest priority, queue must not be empty.
+func (q *priorityQueue) peek() msg {
+	return q.items[0].task
+}
+
+// remove & return task has the highest priority, queue must not be empty.
+func (q *priorityQueue) next() msg {
+	return heap.Pop(q).(queueItem).task
+}
+
+// return time of adding the oldest task, queue must not be empty.
+func (q *priorityQueue) oldest() time.Time {
+	ret := q.items[0].added
+	for _, item := range q.items[1:] {
+		if item.added.Before(ret) {
+			ret = item.added
+		}
+	}
+	return ret
+}
+
+// implement heap.Interface.
+
+func (q *priorityQueue) Len() int {
+	return len(q.items)
+}
+
+func (q *priorityQueue) Less(i, j int) bool {
+	if q.items[i].key != q.items[j].key {
+		return q.items[i].key > q.items[j].key
+	}
+	return q.items[i].seq < q.items[j].seq
+}
+
+func (q *priorityQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+}
+
+func (q *priorityQueue) Push(x any) {
+	q.items = append(q.items, x.(queueItem))
+}
+
+func (q *priorityQueue) Pop() any {
+	n := len(q.items)
+	item := q.items[n-1]
+	q.items = q.items[:n-1]
+	return item
+}
@@ -0,0 +1,175 @@
+package easyworker
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPriorityQueue(t *testing.T) {
+	q := newPriorityQueue(0)
+
+	priorities := []int{0, 5, 0, 10, 5}
+	for i, p := range priorities {
+		q.add(msg{id: i}, p)
+	}
+
+	expected := []int{3, 1, 4, 0, 2}
+	for _, id := range expected {
+		if q.peek().id != id {
+			t.Error("incorrect peek, expected:", id, "got:", q.peek().id)
+		}
+		if got := q.next().id; got != id {
+			t.Error("incorrect order, expected:", id, "got:", got)
+		}
+	}
+
+	if q.Len() != 0 {
+		t.Error("queue isn't empty")
+	}
+}
+
+func TestPriorityQueueAging(t *testing.T) {
+	q := newPriorityQueue(10 * time.Millisecond)
+
+	q.add(msg{id: 0}, 0)
+	time.Sleep(50 * time.Millisecond)
+
+	// low priority task waited more than 2 aging intervals.
+	q.add(msg{id: 1}, 2)
+	// high priority task still runs first.
+	q.add(msg{id: 2}, 10)
+
+	expected := []int{2, 0, 1}
+	for _, id := range expected {
+		if got := q.next().id; got != id {
+			t.Error("incorrect order with aging, expected:", id, "got:", got)
+		}
+	}
+}
+
+func TestPriorityQueueOverflow(t *testing.T) {
+	q := newPriorityQueue(time.Second)
+
+	q.add(msg{id: 0}, 0)
+	q.add(msg{id: 1}, math.MinInt)
+	q.add(msg{id: 2}, math.MaxInt)
+
+	expected := []int{2, 0, 1}
+	for _, id := range expected {
+		if got := q.next().id; got != id {
+			t.Error("incorrect order of large priority, expected:", id, "got:", got)
+		}
+	}
+}
+
+func TestIncorrectPriorityAging(t *testing.T) {
+	config := defaultConfig(add)
+
+	if err := config.SetPriorityAging(-time.Second); err == nil {
+		t.Error("missed checking negative aging")
+	}
+}
+
+func TestTaskPriority(t *testing.T) {
+	var (
+		lock  sync.Mutex
+		order []int
+	)
+
+	fn := func(n int) int {
+		lock.Lock()
+		order = append(order, n)
+		lock.Unlock()
+		return n
+	}
+
+	config, _ := NewConfig(fn, 1, 0, 0)
+	config.SetPriorityAging(0)
+
+	eWorker, _ := NewTask(config)
+	eWorker.AddTask(1)
+	eWorker.AddTaskWithPriority(-1, 2)
+	eWorker.AddTaskWithPriority(5, 3)
+	eWorker.AddTask(4)
+
+	r, err := eWorker.Run()
+	if err != nil {
+		t.Error("run task failed, ", err)
+		return
+	}
+
+	// results are in order of adding.
+	if r[2].([]any)[0] != 3 {
+		t.Error("incorrect result", r)
+	}
+
+	expected := []int{3, 1, 4, 2}
+	for i, n := range expected {
+		if order[i] != n {
+			t.Error("incorrect order of running, expected:", expected, "got:", order)
+			return
+		}
+	}
+}
+
+func TestStreamPriority(t *testing.T) {
+	inCh := make(chan []any)
+	outCh := make(chan any)
+
+	eWorker, _ := NewStream(defaultConfig(strId), inCh, outCh)
+
+	if err := eWorker.SendWithPriority(1, 1, "a"); err == nil {
+		t.Error("send task to a stream isn't running")
+	}
+
+	eWorker.Run()
+	defer eWorker.Stop()
+
+	if err := eWorker.SendWithPriority(1, 1, "a"); err != nil {
+		t.Error("send task with priority failed, ", err)
+		return
+	}
+
+	select {
+	case r := <-outCh:
+		if r.([]any)[0] != "1_a" {
+			t.Error("incorrect result", r)
+		}
+	case <-time.After(time.Second):
+		t.Error("timed out")
+	}
+}
+
+func TestStreamBackpressure(t *testing.T) {
+	inCh := make(chan []any)
+	outCh := make(chan any, 10)
+	release := make(chan struct{})
+
+	config, _ := NewConfig(func(n int) int {
+		<-release
+		return n
+	}, 1, 0, 0)
+
+	eWorker, _ := NewStream(config, inCh, outCh)
+	eWorker.Run()
+	defer eWorker.Stop()
+
+	// worker is blocked, stream receives only a few tasks.
+	accepted := 0
+	for i := 0; i < 10; i++ {
+		select {
+		case inCh <- []any{i}:
+			accepted++
+			continue
+		case <-time.After(50 * time.Millisecond):
+		}
+		break
+	}
+	close(release)
+
+	if accepted > 4 {
+		t.Error("stream doesn't keep backpressure of input channel, accepted:", accepted)
+	}
+}
@@ -0,0 +1,74 @@
+package easyworker
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+/*
+Progress of a run, sent to progress callback (see Config.SetProgress).
+*/
+type Progress struct {
+	// Number of tasks of run, include tasks were added while running.
+	Total int
+
+	// Number of tasks done successfully.
+	Completed int
+
+	// Number of tasks failed (or cancelled).
+	Failed int
+
+	// Number of tasks are running by workers, include retrying tasks.
+	InFlight int
+
+	// Number of running tasks were failed and are retrying.
+	Retrying int
+
+	// Time since run was started.
+	Elapsed time.Duration
+
+	// Number of done tasks (completed & failed) per second.
+	Throughput float64
+
+	// Estimated time to complete all tasks, 0 if throughput is unknown or run is done.
+	ETA time.Duration
+}
+
+// counters of running tasks, updated by workers.
+type progressCounters struct {
+	inFlight atomic.Int64
+	retrying atomic.Int64
+}
+
+// track progress of a batch, used in result loop.
+type progressTracker struct {
+	start     time.Time
+	total     int
+	completed int
+	failed    int
+}
+
+/*
+Make progress from tracker & counters of workers.
+*/
+func (t *progressTracker) snapshot(counters *progressCounters) Progress {
+	ret := Progress{
+		Total:     t.total,
+		Completed: t.completed,
+		Failed:    t.failed,
+		InFlight:  int(counters.inFlight.Load()),
+		Retrying:  int(counters.retrying.Load()),
+		Elapsed:   time.Since(t.start),
+	}
+
+	done := t.completed + t.failed
+	if ret.Elapsed > 0 {
+		ret.Throughput = float64(done) / ret.Elapsed.Seconds()
+	}
+
+	if ret.Throughput > 0 && done < t.total {
+		ret.ETA = time.Duration(float64(t.total-done) / ret.Throughput * float64(time.Second))
+	}
+
+	return ret
+}
@@ -0,0 +1,91 @@
+package easyworker
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProgressSnapshot(t *testing.T) {
+	tracker := progressTracker{start: time.Now().Add(-2 * time.Second), total: 10, completed: 3, failed: 1}
+	counters := progressCounters{}
+	counters.inFlight.Store(2)
+	counters.retrying.Store(1)
+
+	p := tracker.snapshot(&counters)
+
+	if p.Total != 10 || p.Completed != 3 || p.Failed != 1 || p.InFlight != 2 || p.Retrying != 1 {
+		t.Error("incorrect progress", p)
+	}
+
+	if p.Throughput < 1.9 || p.Throughput > 2 {
+		t.Error("incorrect throughput", p.Throughput)
+	}
+
+	if p.ETA < 2900*time.Millisecond || p.ETA > 3100*time.Millisecond {
+		t.Error("incorrect ETA", p.ETA)
+	}
+}
+
+func TestIncorrectProgress(t *testing.T) {
+	config := defaultConfig(add)
+
+	if err := config.SetProgress(0, func(Progress) {}); err == nil {
+		t.Error("missed checking interval")
+	}
+}
+
+func TestTaskProgress(t *testing.T) {
+	slow := func(a int) int {
+		time.Sleep(20 * time.Millisecond)
+		if a%3 == 0 {
+			panic("panic from user func")
+		}
+		return a
+	}
+
+	var (
+		lock    sync.Mutex
+		updates []Progress
+	)
+
+	config, _ := NewConfig(slow, 2, 0, 0)
+	config.SetProgress(10*time.Millisecond, func(p Progress) {
+		lock.Lock()
+		updates = append(updates, p)
+		lock.Unlock()
+	})
+
+	eWorker, _ := NewTask(config)
+	for i := 1; i <= 6; i++ {
+		eWorker.AddTask(i)
+	}
+
+	if _, err := eWorker.Run(); err != nil {
+		t.Error("run task failed, ", err)
+		return
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if len(updates) < 3 {
+		t.Error("progress isn't sent by interval", len(updates))
+		return
+	}
+
+	last := updates[len(updates)-1]
+	if last.Total != 6 || last.Completed != 4 || last.Failed != 2 || last.ETA != 0 {
+		t.Error("incorrect last progress", last)
+	}
+
+	inFlight := false
+	for _, p := range updates {
+		if p.InFlight > 0 {
+			inFlight = true
+		}
+	}
+	if !inFlight {
+		t.Error("in-flight tasks aren't counted")
+	}
+}
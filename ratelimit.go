@@ -0,0 +1,361 @@
+package easyworker
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// number of buckets, limiter removes idle buckets if it has more buckets.
+	iMAX_RATE_BUCKETS = 1024
+
+	// buckets of rate meter, each bucket counts tasks were dispatched in 100ms.
+	iRATE_METER_BUCKETS = 10
+)
+
+// bucket of a key, tokens can be negative for reserved tokens.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+/*
+Token bucket rate limiter for dispatching tasks.
+Limiter is shared between tasks & streams are made from same config.
+*/
+type rateLimiter struct {
+	lock sync.Mutex
+
+	// tokens per second & maximum number of tokens.
+	rate  float64
+	burst int
+
+	// return key of task from params, nil is same bucket for all tasks.
+	key func(args []any) any
+
+	buckets map[any]*tokenBucket
+}
+
+func newRateLimiter(rate float64, burst int, key func(args []any) any) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		burst:   burst,
+		key:     key,
+		buckets: make(map[any]*tokenBucket),
+	}
+}
+
+/*
+Take a token for task, return time to wait before dispatching task.
+For a batch (batching mode), a token is taken for each task with its own params.
+*/
+func (l *rateLimiter) reserve(data any) (ret time.Duration) {
+	if batch, ok := data.([][]any); ok {
+		for _, args := range batch {
+			if d := l.reserve(args); d > ret {
+				ret = d
+			}
+		}
+		return
+	}
+
+	return l.take(l.keyOf(data))
+}
+
+// return key of task, nil if limiter doesn't have key function.
+func (l *rateLimiter) keyOf(data any) any {
+	if l.key == nil {
+		return nil
+	}
+
+	args, ok := data.([]any)
+	if !ok {
+		args = []any{data}
+	}
+	return l.key(args)
+}
+
+/*
+Take a token from bucket of key, return time to wait for token.
+*/
+func (l *rateLimiter) take(key any) time.Duration {
+	now := time.Now()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	b, existed := l.buckets[key]
+	if !existed {
+		if len(l.buckets) >= iMAX_RATE_BUCKETS {
+			l.removeIdle(now)
+		}
+		b = &tokenBucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(l.burst), b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / l.rate * float64(time.Second))
+}
+
+// remove buckets are full, they are same with new buckets. Caller must hold lock.
+func (l *rateLimiter) removeIdle(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// key of batches in throttle, batches have many keys & wait in same lane.
+type batchLane struct{}
+
+// task is waiting for token.
+type delayedTask struct {
+	task msg
+	due  time.Time
+}
+
+/*
+Tasks of a loop of pool (single goroutine) waiting for rate limit.
+Waiting for token is a timer (see wake), loop isn't blocked.
+A task per key waits for token, other tasks of throttled key are pending, tasks of other keys aren't blocked.
+*/
+type throttle struct {
+	p *pool
+
+	// tasks got token, ready for dispatching.
+	ready []msg
+
+	// task is waiting for token of each key.
+	delayed map[any]delayedTask
+
+	// tasks are waiting for delayed task of same key.
+	pending map[any][]msg
+
+	timer    *time.Timer
+	timerDue time.Time
+}
+
+func newThrottle(p *pool) *throttle {
+	return &throttle{
+		p:       p,
+		delayed: make(map[any]delayedTask),
+		pending: make(map[any][]msg),
+	}
+}
+
+/*
+Add a task, task is ready if pool doesn't have rate limit or task got token.
+*/
+func (t *throttle) admit(task msg) {
+	l := t.p.limiter
+	if l == nil {
+		t.ready = append(t.ready, task)
+		return
+	}
+
+	var (
+		lane any
+		d    time.Duration
+	)
+
+	if _, ok := task.data.([][]any); ok {
+		lane = batchLane{}
+	} else {
+		lane = l.keyOf(task.data)
+	}
+
+	// keep order of tasks of a key.
+	if _, existed := t.delayed[lane]; existed {
+		t.pending[lane] = append(t.pending[lane], task)
+		return
+	}
+
+	if _, ok := lane.(batchLane); ok {
+		d = l.reserve(task.data)
+	} else {
+		d = l.take(lane)
+	}
+
+	if d <= 0 {
+		t.ready = append(t.ready, task)
+		return
+	}
+
+	t.p.stats.rateLimited.Add(1)
+	t.p.stats.rateWait.Add(int64(d))
+	t.delayed[lane] = delayedTask{task: task, due: time.Now().Add(d)}
+}
+
+// return the first ready task.
+func (t *throttle) next() (msg, bool) {
+	if len(t.ready) == 0 {
+		return msg{}, false
+	}
+	return t.ready[0], true
+}
+
+// remove the first ready task, it was dispatched.
+func (t *throttle) pop() {
+	t.ready = t.ready[1:]
+}
+
+/*
+Return channel of timer for the earliest delayed task, nil if no task is delayed.
+After timer is fired, release must be called.
+*/
+func (t *throttle) wake() <-chan time.Time {
+	if len(t.delayed) == 0 {
+		return nil
+	}
+
+	var due time.Time
+	for _, d := range t.delayed {
+		if due.IsZero() || d.due.Before(due) {
+			due = d.due
+		}
+	}
+
+	if t.timer == nil || !due.Equal(t.timerDue) {
+		t.stop()
+		t.timer = time.NewTimer(time.Until(due))
+		t.timerDue = due
+	}
+
+	return t.timer.C
+}
+
+/*
+Move delayed tasks got token to ready, the next pending task of each key waits for token.
+*/
+func (t *throttle) release() {
+	t.timer = nil
+
+	now := time.Now()
+
+	released := make([]delayedTask, 0)
+	lanes := make([]any, 0)
+	for lane, d := range t.delayed {
+		if !d.due.After(now) {
+			released = append(released, d)
+			lanes = append(lanes, lane)
+		}
+	}
+
+	// order by time got token.
+	sort.Sort(byDue{released, lanes})
+
+	for i, d := range released {
+		lane := lanes[i]
+		delete(t.delayed, lane)
+		t.ready = append(t.ready, d.task)
+
+		// pending tasks of key get token until a task is delayed.
+		for {
+			if _, existed := t.delayed[lane]; existed {
+				break
+			}
+
+			tasks := t.pending[lane]
+			if len(tasks) == 0 {
+				delete(t.pending, lane)
+				break
+			}
+			t.pending[lane] = tasks[1:]
+			t.admit(tasks[0])
+		}
+	}
+}
+
+// return number of ready & pending tasks, delayed tasks (a task per key) aren't counted.
+func (t *throttle) size() int {
+	ret := len(t.ready)
+	for _, tasks := range t.pending {
+		ret += len(tasks)
+	}
+	return ret
+}
+
+// stop timer of throttle.
+func (t *throttle) stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+// sort released tasks & their keys by time got token.
+type byDue struct {
+	tasks []delayedTask
+	lanes []any
+}
+
+func (b byDue) Len() int {
+	return len(b.tasks)
+}
+
+func (b byDue) Less(i, j int) bool {
+	return b.tasks[i].due.Before(b.tasks[j].due)
+}
+
+func (b byDue) Swap(i, j int) {
+	b.tasks[i], b.tasks[j] = b.tasks[j], b.tasks[i]
+	b.lanes[i], b.lanes[j] = b.lanes[j], b.lanes[i]
+}
+
+/*
+Count tasks were dispatched in the last second.
+*/
+type rateMeter struct {
+	lock    sync.Mutex
+	buckets [iRATE_METER_BUCKETS]int64
+
+	// slot of the last bucket (unix time in 100ms).
+	last int64
+}
+
+func (m *rateMeter) add(now time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.advance(now)
+	m.buckets[m.last%iRATE_METER_BUCKETS]++
+}
+
+func (m *rateMeter) rate(now time.Time) (ret int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.advance(now)
+	for _, n := range m.buckets {
+		ret += n
+	}
+
+	return
+}
+
+// reset buckets are older than 1 second. Caller must hold lock.
+func (m *rateMeter) advance(now time.Time) {
+	slot := now.UnixNano() / int64(time.Second/iRATE_METER_BUCKETS)
+	if slot <= m.last {
+		return
+	}
+
+	if slot-m.last >= iRATE_METER_BUCKETS {
+		m.buckets = [iRATE_METER_BUCKETS]int64{}
+	} else {
+		for i := m.last + 1; i <= slot; i++ {
+			m.buckets[i%iRATE_METER_BUCKETS] = 0
+		}
+	}
+	m.last = slot
+}
@@ -0,0 +1,235 @@
+package easyworker
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(10, 2, nil)
+
+	// burst.
+	for i := 0; i < 2; i++ {
+		if d := l.reserve([]any{i}); d != 0 {
+			t.Error("burst task must not wait", d)
+		}
+	}
+
+	if d := l.reserve([]any{3}); d < 90*time.Millisecond || d > 100*time.Millisecond {
+		t.Error("incorrect waiting time", d)
+	}
+
+	if d := l.reserve([]any{4}); d < 190*time.Millisecond || d > 200*time.Millisecond {
+		t.Error("incorrect waiting time of reserved token", d)
+	}
+}
+
+func TestRateLimiterKey(t *testing.T) {
+	l := newRateLimiter(1, 1, func(args []any) any {
+		return args[0]
+	})
+
+	if l.reserve([]any{"a"}) != 0 || l.reserve([]any{"b"}) != 0 {
+		t.Error("keys must have own bucket")
+	}
+
+	if l.reserve([]any{"a", 1}) == 0 {
+		t.Error("key is out of token")
+	}
+
+	// not []any data (TaskOf).
+	if l.reserve("c") != 0 || l.reserve("c") == 0 {
+		t.Error("incorrect key of single input")
+	}
+}
+
+func TestRateMeter(t *testing.T) {
+	m := rateMeter{}
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		m.add(now.Add(time.Duration(i) * 100 * time.Millisecond))
+	}
+
+	if r := m.rate(now.Add(400 * time.Millisecond)); r != 5 {
+		t.Error("incorrect rate", r)
+	}
+
+	if r := m.rate(now.Add(1200 * time.Millisecond)); r != 2 {
+		t.Error("incorrect rate after sliding", r)
+	}
+
+	if r := m.rate(now.Add(5 * time.Second)); r != 0 {
+		t.Error("incorrect rate after idle", r)
+	}
+}
+
+func TestIncorrectRateLimit(t *testing.T) {
+	config := defaultConfig(add)
+
+	if err := config.SetRateLimit(-1, 1, nil); err == nil {
+		t.Error("missed checking negative rate")
+	}
+
+	if err := config.SetRateLimit(1, 0, nil); err == nil {
+		t.Error("missed checking burst")
+	}
+}
+
+func TestTaskRateLimit(t *testing.T) {
+	config, _ := NewConfig(add, 3, 0, 0)
+	config.SetRateLimit(50, 1, nil)
+
+	eWorker, _ := NewTask(config)
+	for i := 0; i < 6; i++ {
+		eWorker.AddTask(i, i)
+	}
+
+	start := time.Now()
+	if _, err := eWorker.Run(); err != nil {
+		t.Error("run task failed, ", err)
+		return
+	}
+
+	// 5 tasks wait 20ms for each.
+	if time.Since(start) < 90*time.Millisecond {
+		t.Error("rate limit isn't honored", time.Since(start))
+	}
+
+	stats := eWorker.Stats()
+	if stats.RateLimited != 5 || stats.RateWait <= 0 || stats.Rate != 6 {
+		t.Error("incorrect stats", stats)
+	}
+}
+
+// run tasks with a key throttled, return time of starting each key.
+func runKeyedRateLimit(t *testing.T, run func(config Config, fn any) error) map[string][]time.Duration {
+	var (
+		lock    sync.Mutex
+		started = make(map[string][]time.Duration)
+		start   = time.Now()
+	)
+
+	fn := func(key string) string {
+		lock.Lock()
+		started[key] = append(started[key], time.Since(start))
+		lock.Unlock()
+		return key
+	}
+
+	config, _ := NewConfig(fn, 2, 0, 0)
+	config.SetRateLimit(5, 1, func(args []any) any {
+		return args[0]
+	})
+
+	if err := run(config, fn); err != nil {
+		t.Error("run failed,", err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	return started
+}
+
+func checkKeyedRateLimit(t *testing.T, started map[string][]time.Duration) {
+	// other keys aren't blocked by throttled key.
+	for _, key := range []string{"b", "c"} {
+		if len(started[key]) != 1 || started[key][0] > 100*time.Millisecond {
+			t.Error("task is blocked by other key", key, started[key])
+		}
+	}
+
+	if a := started["a"]; len(a) != 3 || a[1] < 150*time.Millisecond || a[2] < 350*time.Millisecond {
+		t.Error("rate limit of key isn't honored", a)
+	}
+}
+
+func TestTaskRateLimitKeyNotBlocked(t *testing.T) {
+	started := runKeyedRateLimit(t, func(config Config, fn any) error {
+		eWorker, _ := NewTask(config)
+		for _, key := range []string{"a", "a", "a", "b", "c"} {
+			eWorker.AddTask(key)
+		}
+
+		_, err := eWorker.Run()
+		return err
+	})
+
+	checkKeyedRateLimit(t, started)
+}
+
+func TestStreamRateLimitKeyNotBlocked(t *testing.T) {
+	started := runKeyedRateLimit(t, func(config Config, fn any) error {
+		inCh := make(chan []any)
+		outCh := make(chan any, 5)
+
+		stream, _ := NewStream(config, inCh, outCh)
+		if err := stream.Run(); err != nil {
+			return err
+		}
+		defer stream.Stop()
+
+		for _, key := range []string{"a", "a", "a", "b", "c"} {
+			inCh <- []any{key}
+		}
+
+		for i := 0; i < 5; i++ {
+			select {
+			case <-outCh:
+			case <-time.After(time.Second):
+				t.Error("timed out")
+				return nil
+			}
+		}
+		return nil
+	})
+
+	checkKeyedRateLimit(t, started)
+}
+
+func TestTaskRateLimitPriority(t *testing.T) {
+	var (
+		lock  sync.Mutex
+		order []int
+	)
+
+	fn := func(n int) int {
+		lock.Lock()
+		order = append(order, n)
+		lock.Unlock()
+		return n
+	}
+
+	config, _ := NewConfig(fn, 1, 0, 0)
+	config.SetRateLimit(20, 1, nil)
+
+	eWorker, _ := NewTask(config)
+	for i := 0; i < 10; i++ {
+		eWorker.AddTask(i)
+	}
+
+	go func() {
+		time.Sleep(120 * time.Millisecond)
+		eWorker.AddTaskWithPriority(100, 999)
+	}()
+
+	if _, err := eWorker.Run(); err != nil {
+		t.Error("run task failed, ", err)
+		return
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	for i, n := range order {
+		if n == 999 {
+			if i > 7 {
+				t.Error("high priority task doesn't run first with rate limit", order)
+			}
+			return
+		}
+	}
+	t.Error("added task wasn't run", order)
+}
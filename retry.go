@@ -0,0 +1,216 @@
+package easyworker
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	// Same delay for every retry.
+	BACKOFF_FIXED = iota
+
+	// Delay is doubled after each retry.
+	BACKOFF_EXPONENTIAL
+
+	// Delay is increased by base delay after each retry.
+	BACKOFF_LINEAR
+)
+
+const (
+	// No jitter, use exactly delay of backoff.
+	JITTER_NONE = iota
+
+	// Random delay between 0 and delay of backoff.
+	JITTER_FULL
+
+	// Random delay between base delay and 3 times of the last delay (backoff is ignored).
+	JITTER_DECORRELATED
+)
+
+/*
+Retry policy for re-running a failed task.
+Zero value is a valid policy (run one time, no retry).
+*/
+type RetryPolicy struct {
+	// Maximum number of attempts, include the first run. Value less than 1 is treated as 1.
+	MaxAttempts int
+
+	// Kind of backoff (BACKOFF_FIXED, BACKOFF_EXPONENTIAL, BACKOFF_LINEAR)
+	Backoff int
+
+	// Delay before the first retry.
+	Delay time.Duration
+
+	// Maximum delay between retries. 0 is no limit.
+	MaxDelay time.Duration
+
+	// Kind of jitter (JITTER_NONE, JITTER_FULL, JITTER_DECORRELATED)
+	Jitter int
+
+	// Total time for all attempts, task isn't retried if the next attempt is started after deadline. 0 is no limit.
+	Deadline time.Duration
+
+	// Decide if a failure can retry. nil is retry for all failures.
+	// Panic of user function is passed as *PanicError, errors marked by Permanent are never retried.
+	Retryable func(err error) bool
+}
+
+/*
+Check if policy is correct.
+*/
+func (p RetryPolicy) validate() error {
+	if p.Backoff < BACKOFF_FIXED || p.Backoff > BACKOFF_LINEAR {
+		return fmt.Errorf("incorrect backoff, input: %d", p.Backoff)
+	}
+
+	if p.Jitter < JITTER_NONE || p.Jitter > JITTER_DECORRELATED {
+		return fmt.Errorf("incorrect jitter, input: %d", p.Jitter)
+	}
+
+	if p.Delay < 0 || p.MaxDelay < 0 || p.Deadline < 0 {
+		return errors.New("delay of retry policy is negative")
+	}
+
+	return nil
+}
+
+/*
+Return maximum number of attempts.
+*/
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+/*
+Check if failure can retry.
+*/
+func (p RetryPolicy) canRetry(err error) bool {
+	if IsPermanent(err) {
+		return false
+	}
+
+	if p.Retryable == nil {
+		return true
+	}
+	return p.Retryable(err)
+}
+
+/*
+Return delay before the next retry, false if task cannot retry anymore.
+attempts is number of attempts were run, prev is the last delay, start is time of the first attempt.
+*/
+func (p RetryPolicy) next(attempts int, prev time.Duration, start time.Time, err error) (time.Duration, bool) {
+	if attempts >= p.attempts() || !p.canRetry(err) {
+		return 0, false
+	}
+
+	d := p.delay(attempts, prev)
+
+	if p.Deadline > 0 && time.Since(start)+d > p.Deadline {
+		return 0, false
+	}
+
+	return d, true
+}
+
+/*
+Return delay before a retry. retry is started from 1, prev is delay of the last retry.
+*/
+func (p RetryPolicy) delay(retry int, prev time.Duration) time.Duration {
+	d := p.Delay
+
+	switch p.Backoff {
+	case BACKOFF_EXPONENTIAL:
+		for i := 1; i < retry; i++ {
+			// stop if overflow or reach max delay.
+			if d > d*2 || (p.MaxDelay > 0 && d >= p.MaxDelay) {
+				break
+			}
+			d *= 2
+		}
+	case BACKOFF_LINEAR:
+		for i := 1; i < retry; i++ {
+			// stop if overflow or reach max delay.
+			if d > d+p.Delay || (p.MaxDelay > 0 && d >= p.MaxDelay) {
+				break
+			}
+			d += p.Delay
+		}
+	}
+
+	switch p.Jitter {
+	case JITTER_FULL:
+		if p.MaxDelay > 0 && d > p.MaxDelay {
+			d = p.MaxDelay
+		}
+		if d > 0 {
+			d = time.Duration(rand.Int63n(int64(d)))
+		}
+	case JITTER_DECORRELATED:
+		if prev < p.Delay {
+			prev = p.Delay
+		}
+		d = p.Delay
+		if upper := prev * 3; upper > d {
+			d += time.Duration(rand.Int63n(int64(upper - d)))
+		}
+	}
+
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	return d
+}
+
+// error cannot be fixed by retry.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+/*
+Mark an error as permanent, task failed with this error isn't retried.
+Original error can be checked by errors.Is/errors.As. Return nil if err is nil.
+TaskOf & batch function fail by returning the error. Function of EasyTask & EasyStream (called by reflect)
+fails only by panic, an error returned by it is a result value, panic with permanent error instead.
+
+Example:
+
+	// TaskOf.
+	if n < 0 {
+		return 0, easyworker.Permanent(errors.New("n is negative"))
+	}
+
+	// EasyTask & EasyStream.
+	if n < 0 {
+		panic(easyworker.Permanent(errors.New("n is negative")))
+	}
+*/
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+/*
+Check if error (or an error it wraps) was marked by Permanent.
+*/
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,242 @@
+package easyworker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyAttempts(t *testing.T) {
+	if (RetryPolicy{}).attempts() != 1 {
+		t.Error("zero value policy must run one time")
+	}
+
+	if (RetryPolicy{MaxAttempts: 3}).attempts() != 3 {
+		t.Error("incorrect number of attempts")
+	}
+}
+
+func TestRetryPolicyFixed(t *testing.T) {
+	p := RetryPolicy{Backoff: BACKOFF_FIXED, Delay: 10 * time.Millisecond}
+
+	for i := 1; i < 5; i++ {
+		if p.delay(i, 0) != 10*time.Millisecond {
+			t.Error("incorrect fixed delay at retry", i, p.delay(i, 0))
+		}
+	}
+}
+
+func TestRetryPolicyExponential(t *testing.T) {
+	p := RetryPolicy{Backoff: BACKOFF_EXPONENTIAL, Delay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+	expected := []time.Duration{10, 20, 40, 50, 50}
+	for i, d := range expected {
+		if p.delay(i+1, 0) != d*time.Millisecond {
+			t.Error("incorrect exponential delay at retry", i+1, p.delay(i+1, 0))
+		}
+	}
+}
+
+func TestRetryPolicyJitter(t *testing.T) {
+	p := RetryPolicy{Backoff: BACKOFF_FIXED, Delay: 10 * time.Millisecond, Jitter: JITTER_FULL}
+
+	for i := 1; i < 100; i++ {
+		if d := p.delay(i, 0); d < 0 || d >= 10*time.Millisecond {
+			t.Error("jitter delay is out of range", d)
+		}
+	}
+}
+
+func TestRetryPolicyLinear(t *testing.T) {
+	p := RetryPolicy{Backoff: BACKOFF_LINEAR, Delay: 10 * time.Millisecond, MaxDelay: 35 * time.Millisecond}
+
+	expected := []time.Duration{10, 20, 30, 35, 35}
+	for i, d := range expected {
+		if p.delay(i+1, 0) != d*time.Millisecond {
+			t.Error("incorrect linear delay at retry", i+1, p.delay(i+1, 0))
+		}
+	}
+}
+
+func TestRetryPolicyDecorrelated(t *testing.T) {
+	p := RetryPolicy{Delay: 10 * time.Millisecond, MaxDelay: 100 * time.Millisecond, Jitter: JITTER_DECORRELATED}
+
+	prev := time.Duration(0)
+	for i := 1; i < 100; i++ {
+		d := p.delay(i, prev)
+
+		upper := prev * 3
+		if upper < 30*time.Millisecond {
+			upper = 30 * time.Millisecond
+		}
+		if d < 10*time.Millisecond || d > upper || d > 100*time.Millisecond {
+			t.Error("decorrelated delay is out of range", d, "last delay:", prev)
+		}
+		prev = d
+	}
+}
+
+func TestRetryPolicyDeadline(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, Delay: 30 * time.Millisecond, Deadline: 50 * time.Millisecond}
+
+	start := time.Now()
+	if _, ok := p.next(1, 0, start, nil); !ok {
+		t.Error("retry is stopped before deadline")
+	}
+
+	if _, ok := p.next(1, 0, start.Add(-30*time.Millisecond), nil); ok {
+		t.Error("retry is continued after deadline")
+	}
+
+	if _, ok := p.next(10, 0, start, nil); ok {
+		t.Error("retry is continued after max attempts")
+	}
+}
+
+func TestIncorrectRetryPolicy(t *testing.T) {
+	config := defaultConfig(add)
+
+	for _, p := range []RetryPolicy{{Backoff: 100}, {Jitter: 100}, {Delay: -1}, {Deadline: -1}} {
+		if err := config.SetRetryPolicy(p); err == nil {
+			t.Error("incorrect retry policy is passed", p)
+		}
+	}
+}
+
+func TestTaskRetrySleep(t *testing.T) {
+	counter := 0
+	failTwice := func() int {
+		counter++
+		if counter < 3 {
+			panic("test retry sleep")
+		}
+		return counter
+	}
+
+	config, _ := NewConfig(failTwice, 1, 3, 20)
+	eWorker, _ := NewTask(config)
+	eWorker.AddTask()
+
+	start := time.Now()
+	r, _ := eWorker.Run()
+
+	if r[0].([]any)[0].(int) != 3 {
+		t.Error("incorrect result", r)
+	}
+
+	if time.Since(start) < 40*time.Millisecond {
+		t.Error("retrySleep isn't honored")
+	}
+}
+
+func TestPermanentError(t *testing.T) {
+	errBase := errors.New("bad input")
+	err := fmt.Errorf("task failed, %w", Permanent(errBase))
+
+	if !IsPermanent(err) || !errors.Is(err, errBase) {
+		t.Error("permanent error isn't detected", err)
+	}
+
+	if Permanent(nil) != nil || IsPermanent(errBase) {
+		t.Error("incorrect permanent error")
+	}
+
+	p := RetryPolicy{MaxAttempts: 3}
+	if _, ok := p.next(1, 0, time.Now(), err); ok {
+		t.Error("permanent error is retried")
+	}
+}
+
+func TestTaskOfRetryPermanent(t *testing.T) {
+	config, _ := NewPoolConfig(2, 3, 0)
+
+	task, _ := NewTaskOf(func(ctx context.Context, n int) (int, error) {
+		if n < 0 {
+			return 0, Permanent(errors.New("negative number"))
+		}
+		return 0, errors.New("temporary error")
+	}, config)
+	task.Add(-1)
+	task.Add(1)
+
+	results, _ := task.Run(context.Background())
+
+	if results[0].Err == nil || results[0].Attempts != 1 {
+		t.Error("permanent error is retried", results[0])
+	}
+
+	if results[1].Err == nil || results[1].Attempts != 4 {
+		t.Error("incorrect number of attempts", results[1])
+	}
+}
+
+func TestTaskRetryPermanentPanic(t *testing.T) {
+	errNegative := errors.New("negative number")
+
+	fn := func(n int) int {
+		if n < 0 {
+			panic(Permanent(errNegative))
+		}
+		panic("temporary error")
+	}
+
+	config, _ := NewConfig(fn, 2, 3, 0)
+	eWorker, _ := NewTask(config)
+	eWorker.AddTask(-1)
+	eWorker.AddTask(1)
+
+	eWorker.Run()
+	results := eWorker.GetResults()
+
+	if !errors.Is(results[0].Err, errNegative) || !IsPermanent(results[0].Err) || results[0].Attempts != 1 {
+		t.Error("permanent error is retried", results[0])
+	}
+
+	if results[1].Err == nil || results[1].Attempts != 4 {
+		t.Error("incorrect number of attempts", results[1])
+	}
+}
+
+func TestTaskRetryableClassifier(t *testing.T) {
+	errFatal := errors.New("fatal")
+
+	config, _ := NewPoolConfig(1, 3, 0)
+	config.SetRetryable(func(err error) bool {
+		if errors.Is(err, errFatal) {
+			return false
+		}
+
+		var p *PanicError
+		if errors.As(err, &p) {
+			return p.Value != "fatal panic"
+		}
+		return true
+	})
+
+	task, _ := NewTaskOf(func(ctx context.Context, n int) (int, error) {
+		switch n {
+		case 0:
+			return 0, errFatal
+		case 1:
+			panic("fatal panic")
+		case 2:
+			panic(errFatal)
+		}
+		panic("other panic")
+	}, config)
+
+	for i := 0; i < 4; i++ {
+		task.Add(i)
+	}
+
+	results, _ := task.Run(context.Background())
+
+	expected := []int{1, 1, 1, 4}
+	for i, r := range results {
+		if r.Err == nil || r.Attempts != expected[i] {
+			t.Error("incorrect classification, task", i, r)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package easyworker
+
+/*
+Common interface for background processes (Go, Child, EasyTask, EasyStream).
+Supervisor can supervise any Runnable by AddRunnable.
+*/
+type Runnable interface {
+	// Start process in background.
+	Start() error
+
+	// Stop process.
+	Stop() error
+
+	// Wait for running task of process done. Return error of the last run.
+	Wait() error
+
+	// Return state of process (STANDBY, RUNNING, STOPPED, ...).
+	State() int64
+
+	// Used for receiving a signal when a run of process done or failed.
+	Monitor() (int64, <-chan GoSignal)
+
+	// Remove a monitor reference.
+	Demonitor(refId int64)
+}
+
+var (
+	_ Runnable = (*Go)(nil)
+	_ Runnable = (*Child)(nil)
+	_ Runnable = (*EasyTask)(nil)
+	_ Runnable = (*EasyStream)(nil)
+)
@@ -0,0 +1,162 @@
+package easyworker
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGoWait(t *testing.T) {
+	g, _ := NewGo(loopRun2, 5)
+
+	g.Start()
+
+	if err := g.Wait(); err != nil {
+		t.Error("Go failed, ", err)
+	}
+
+	if g.GetResult()[0].(int) != 10 || g.State() != STANDBY {
+		t.Error("Go isn't done after Wait")
+	}
+
+	g, _ = NewGo(simpleLoopWithPanic, 5)
+	g.Start()
+
+	if err := g.Wait(); err == nil {
+		t.Error("Wait missed error of Go")
+	}
+}
+
+func TestChildStartWithoutSupervisor(t *testing.T) {
+	child, _ := NewChild(NO_RESTART, loopRun2, 5)
+
+	_, ch := child.Monitor()
+	child.Start()
+
+	select {
+	case sig := <-ch:
+		if sig.Signal != SIGNAL_DONE || child.State() != STOPPED {
+			t.Error("incorrect signal", sig)
+		}
+	case <-time.After(time.Second):
+		t.Error("timed out")
+	}
+}
+
+func TestTaskStartAndWait(t *testing.T) {
+	task, _ := NewTask(defaultConfig(sum))
+
+	task.AddTask(1, 2, 3)
+	task.AddTask(4, 5)
+
+	if err := task.Start(); err != nil {
+		t.Error("start task failed, ", err)
+		return
+	}
+
+	if err := task.Start(); err == nil {
+		t.Error("task is started twice")
+	}
+
+	if err := task.Wait(); err != nil {
+		t.Error("task failed, ", err)
+	}
+
+	r := task.GetResult()
+	if len(r) != 2 || r[0].([]any)[0].(int) != 6 || r[1].([]any)[0].(int) != 9 {
+		t.Error("incorrect result", r)
+	}
+}
+
+func TestStreamMonitor(t *testing.T) {
+	inCh := make(chan []any)
+	outCh := make(chan any)
+
+	stream, _ := NewStream(defaultConfig(strId), inCh, outCh)
+
+	_, ch := stream.Monitor()
+	stream.Start()
+
+	if stream.State() != RUNNING {
+		t.Error("stream isn't running")
+	}
+
+	stream.Stop()
+
+	select {
+	case sig := <-ch:
+		if sig.Signal != SIGNAL_DONE || stream.State() != STOPPED {
+			t.Error("incorrect signal", sig)
+		}
+	case <-time.After(time.Second):
+		t.Error("timed out")
+	}
+}
+
+func TestSupervisorRunnable(t *testing.T) {
+	var counter atomic.Int64
+	failTwice := func() {
+		if counter.Add(1) < 3 {
+			panic("test supervisor runnable")
+		}
+	}
+
+	g, _ := NewGo(failTwice)
+
+	sup := NewSupervisor()
+
+	id, err := sup.AddRunnable(ERROR_RESTART, g)
+	if err != nil {
+		t.Error("add runnable failed, ", err)
+		return
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	_, restarted, failed, err := sup.RunnableStats(id)
+	if err != nil || restarted != 2 || failed != 2 || counter.Load() != 3 {
+		t.Error("incorrect restart, restarted:", restarted, "failed:", failed, "runs:", counter.Load())
+	}
+
+	if sup.GetRunnable(id) != g {
+		t.Error("incorrect runnable")
+	}
+
+	sup.RemoveRunnable(id)
+
+	if sup.GetRunnable(id) != nil {
+		t.Error("runnable isn't removed")
+	}
+
+	RemoveSupervisor(&sup)
+}
+
+func TestSupervisorStream(t *testing.T) {
+	inCh := make(chan []any)
+	outCh := make(chan any)
+
+	stream, _ := NewStream(defaultConfig(strId), inCh, outCh)
+
+	sup := NewSupervisor()
+	sup.AddRunnable(ALWAYS_RESTART, &stream)
+
+	inCh <- []any{1, "hello"}
+	if r := <-outCh; r.([]any)[0] != "1_hello" {
+		t.Error("incorrect result", r)
+	}
+
+	total, running, _, _ := sup.Stats()
+	if total != 1 || running != 1 {
+		t.Error("incorrect stats", total, running)
+	}
+
+	sup.Stop()
+	time.Sleep(10 * time.Millisecond)
+
+	if stream.State() != STOPPED {
+		t.Error("stream is restarted after supervisor stopped")
+	}
+
+	sup.Done()
+	RemoveSupervisor(&sup)
+}
@@ -0,0 +1,400 @@
+package easyworker
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Skip a scheduled run if the previous run isn't done.
+	OVERLAP_SKIP = iota
+
+	// Wait for the previous run done then run.
+	OVERLAP_QUEUE
+
+	// Run concurrently with the previous run.
+	OVERLAP_ALLOW
+)
+
+const (
+	// Runs were missed (Go was busy or system was sleeping) will be run once, as soon as possible.
+	MISSED_RUN_ONCE = iota
+
+	// Runs were missed will be skipped, wait for the next scheduled time.
+	MISSED_SKIP
+)
+
+/*
+Options for scheduled runs of Go.
+Zero value is a valid options (skip overlap run, run missed runs once).
+*/
+type ScheduleOptions struct {
+	// Policy if a run is scheduled while the previous run isn't done (OVERLAP_SKIP, OVERLAP_QUEUE, OVERLAP_ALLOW).
+	Overlap int
+
+	// Policy for missed runs (MISSED_RUN_ONCE, MISSED_SKIP).
+	Missed int
+}
+
+/*
+Run Go once after a delay.
+*/
+func (g *Go) RunAfter(delay time.Duration) error {
+	if delay < 0 {
+		return fmt.Errorf("delay is negative, %s", delay)
+	}
+
+	fired := false
+	next := func(last time.Time) time.Time {
+		if fired {
+			return time.Time{}
+		}
+		fired = true
+		return last.Add(delay)
+	}
+
+	return g.schedule(next, false, ScheduleOptions{Overlap: OVERLAP_ALLOW})
+}
+
+/*
+Run Go at a fixed rate. The first run is started after interval.
+Schedule is ended by Stop or CancelSchedule.
+*/
+func (g *Go) RunEvery(interval time.Duration, opts ScheduleOptions) error {
+	if interval <= 0 {
+		return fmt.Errorf("interval must be positive, %s", interval)
+	}
+
+	next := func(last time.Time) time.Time {
+		return last.Add(interval)
+	}
+
+	return g.schedule(next, false, opts)
+}
+
+/*
+Run Go with a fixed delay between the end of a run and the start of the next run.
+The first run is started after delay. Runs are never overlapped.
+Schedule is ended by Stop or CancelSchedule.
+*/
+func (g *Go) RunWithDelay(delay time.Duration) error {
+	if delay <= 0 {
+		return fmt.Errorf("delay must be positive, %s", delay)
+	}
+
+	next := func(last time.Time) time.Time {
+		return last.Add(delay)
+	}
+
+	return g.schedule(next, true, ScheduleOptions{})
+}
+
+/*
+Run Go by a standard 5-field cron expression (minute hour day-of-month month day-of-week).
+Schedule is ended by Stop or CancelSchedule.
+
+Example:
+
+	// run at 8:30 on weekdays.
+	g.RunCron("30 8 * * 1-5", easyworker.ScheduleOptions{})
+*/
+func (g *Go) RunCron(expr string, opts ScheduleOptions) error {
+	cron, err := ParseCron(expr)
+	if err != nil {
+		return err
+	}
+
+	return g.schedule(cron.Next, false, opts)
+}
+
+/*
+End schedule of Go. Running task isn't affected.
+*/
+func (g *Go) CancelSchedule() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.scheduleStop != nil {
+		close(g.scheduleStop)
+		g.scheduleStop = nil
+	}
+}
+
+/*
+Start a goroutine to run Go by schedule.
+next returns time of the next run from time of the last run, zero time for ending schedule.
+*/
+func (g *Go) schedule(next func(time.Time) time.Time, fixedDelay bool, opts ScheduleOptions) error {
+	if opts.Overlap < OVERLAP_SKIP || opts.Overlap > OVERLAP_ALLOW {
+		return fmt.Errorf("incorrect overlap policy, input: %d", opts.Overlap)
+	}
+
+	if opts.Missed < MISSED_RUN_ONCE || opts.Missed > MISSED_SKIP {
+		return fmt.Errorf("incorrect missed run policy, input: %d", opts.Missed)
+	}
+
+	if g.state.Load() == STOPPED {
+		return errors.New("Go cannot run, it stopped")
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.scheduleStop != nil {
+		return errors.New("Go is already scheduled")
+	}
+
+	stop := make(chan struct{})
+	g.scheduleStop = stop
+
+	go g.runSchedule(stop, next, fixedDelay, opts)
+
+	return nil
+}
+
+func (g *Go) runSchedule(stop chan struct{}, next func(time.Time) time.Time, fixedDelay bool, opts ScheduleOptions) {
+	defer func() {
+		g.lock.Lock()
+		if g.scheduleStop == stop {
+			g.scheduleStop = nil
+		}
+		g.lock.Unlock()
+	}()
+
+	// use for checking overlap, has a value if a scheduled run isn't done.
+	busy := make(chan struct{}, 1)
+
+	at := next(time.Now())
+	for !at.IsZero() {
+		timer := time.NewTimer(time.Until(at))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if g.state.Load() == STOPPED {
+			return
+		}
+
+		if fixedDelay {
+			g.begin()
+			g.run_task()
+			at = next(time.Now())
+			continue
+		}
+
+		// number of scheduled times were passed, include time of timer.
+		now := time.Now()
+		passed := 0
+		for !at.IsZero() && !at.After(now) {
+			passed++
+			at = next(at)
+		}
+
+		// timer was late more than a run (Go was busy or system was sleeping).
+		if passed > 1 {
+			if printLog {
+				log.Println(g.id, "Go missed", passed, "scheduled runs")
+			}
+
+			if opts.Missed == MISSED_SKIP {
+				continue
+			}
+		}
+
+		if !g.fire(stop, busy, opts.Overlap) {
+			return
+		}
+	}
+}
+
+/*
+Start a scheduled run by overlap policy.
+Return false if schedule was ended while waiting.
+*/
+func (g *Go) fire(stop chan struct{}, busy chan struct{}, overlap int) bool {
+	switch overlap {
+	case OVERLAP_ALLOW:
+		g.begin()
+		go g.run_task()
+		return true
+	case OVERLAP_SKIP:
+		select {
+		case busy <- struct{}{}:
+		default:
+			if printLog {
+				log.Println(g.id, "Go is running, skip scheduled run")
+			}
+			return true
+		}
+	case OVERLAP_QUEUE:
+		select {
+		case busy <- struct{}{}:
+		case <-stop:
+			return false
+		}
+	}
+
+	g.begin()
+	go func() {
+		defer func() { <-busy }()
+		g.run_task()
+	}()
+
+	return true
+}
+
+/*
+A parsed cron expression.
+*/
+type CronExpr struct {
+	minute, hour, dom, month, dow uint64
+
+	// day-of-month & day-of-week aren't restricted (start with '*').
+	domStar, dowStar bool
+}
+
+/*
+Parse a standard 5-field cron expression: minute hour day-of-month month day-of-week.
+Each field supports '*', numbers, ranges (a-b), lists (a,b) and steps (a-b/n, '*' with step is full range).
+Day-of-week is 0-7, both 0 and 7 are Sunday.
+*/
+func ParseCron(expr string) (ret *CronExpr, err error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		err = fmt.Errorf("cron expression must have 5 fields, input: %q", expr)
+		return
+	}
+
+	ret = &CronExpr{
+		domStar: strings.HasPrefix(fields[2], "*"),
+		dowStar: strings.HasPrefix(fields[4], "*"),
+	}
+
+	if ret.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if ret.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if ret.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if ret.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if ret.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+
+	// 7 is Sunday.
+	if ret.dow&(1<<7) != 0 {
+		ret.dow |= 1
+	}
+
+	return
+}
+
+/*
+Parse a field of cron expression to a bit set.
+*/
+func parseCronField(field string, min, max int) (ret uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		hasStep := false
+		if i := strings.Index(part, "/"); i >= 0 {
+			hasStep = true
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("incorrect step in cron field %q", field)
+			}
+			part = part[:i]
+		}
+
+		low, high := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("incorrect range in cron field %q", field)
+			}
+			if high, err = strconv.Atoi(bounds[1]); err != nil {
+				return 0, fmt.Errorf("incorrect range in cron field %q", field)
+			}
+		default:
+			if low, err = strconv.Atoi(part); err != nil {
+				return 0, fmt.Errorf("incorrect value in cron field %q", field)
+			}
+			high = low
+			// a value with step is started from value to max (same with Vixie cron).
+			if hasStep {
+				high = max
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("cron field %q is out of range [%d, %d]", field, min, max)
+		}
+
+		for v := low; v <= high; v += step {
+			ret |= 1 << uint(v)
+		}
+	}
+
+	return
+}
+
+/*
+Return the next time (after t) matching the expression.
+Return zero time if no time is matched in the next 5 years.
+*/
+func (c *CronExpr) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+/*
+Check day of time. If both day-of-month & day-of-week are restricted, time is matched by one of them.
+*/
+func (c *CronExpr) matchDay(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
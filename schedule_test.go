@@ -0,0 +1,177 @@
+package easyworker
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseCronIncorrect(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Error("missed checking cron expression", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	base := time.Date(2024, time.January, 1, 10, 15, 30, 0, time.UTC) // Monday
+
+	cases := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.January, 1, 10, 16, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2024, time.January, 1, 10, 20, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2024, time.January, 2, 9, 0, 0, 0, time.UTC)},
+		{"30 8 * * 6,7", time.Date(2024, time.January, 6, 8, 30, 0, 0, time.UTC)},
+		{"0 0 1 3 *", time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// day-of-month or day-of-week.
+		{"0 0 15 * 3", time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		cron, err := ParseCron(c.expr)
+		if err != nil {
+			t.Error("parse cron failed,", c.expr, err)
+			continue
+		}
+
+		if next := cron.Next(base); !next.Equal(c.next) {
+			t.Error("incorrect next time for", c.expr, "expected:", c.next, "got:", next)
+		}
+	}
+}
+
+func TestCronNoNext(t *testing.T) {
+	cron, _ := ParseCron("0 0 31 2 *")
+
+	if !cron.Next(time.Now()).IsZero() {
+		t.Error("expected no next time")
+	}
+}
+
+func TestGoRunAfter(t *testing.T) {
+	g, _ := NewGo(loopRun2, 5)
+
+	_, ch := g.Monitor()
+	start := time.Now()
+
+	if err := g.RunAfter(50 * time.Millisecond); err != nil {
+		t.Error("schedule failed,", err)
+		return
+	}
+
+	select {
+	case <-ch:
+		if time.Since(start) < 50*time.Millisecond {
+			t.Error("Go ran before delay")
+		}
+	case <-time.After(time.Second):
+		t.Error("timed out")
+	}
+}
+
+func TestGoRunEvery(t *testing.T) {
+	var counter atomic.Int64
+	g, _ := NewGo(func() { counter.Add(1) })
+
+	if err := g.RunEvery(10*time.Millisecond, ScheduleOptions{}); err != nil {
+		t.Error("schedule failed,", err)
+		return
+	}
+
+	if err := g.RunEvery(10*time.Millisecond, ScheduleOptions{}); err == nil {
+		t.Error("Go is scheduled twice")
+	}
+
+	time.Sleep(105 * time.Millisecond)
+	g.Stop()
+	time.Sleep(20 * time.Millisecond)
+
+	n := counter.Load()
+	if n < 5 || n > 11 {
+		t.Error("incorrect number of runs", n)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if counter.Load() != n {
+		t.Error("schedule isn't ended by Stop")
+	}
+}
+
+func TestGoRunEverySkipOverlap(t *testing.T) {
+	var counter atomic.Int64
+	g, _ := NewGo(func() {
+		counter.Add(1)
+		time.Sleep(50 * time.Millisecond)
+	})
+
+	g.RunEvery(10*time.Millisecond, ScheduleOptions{Overlap: OVERLAP_SKIP})
+
+	time.Sleep(125 * time.Millisecond)
+	g.CancelSchedule()
+
+	if n := counter.Load(); n < 2 || n > 3 {
+		t.Error("overlap runs aren't skipped", n)
+	}
+}
+
+func TestGoRunWithDelay(t *testing.T) {
+	var running, overlapped atomic.Int64
+	g, _ := NewGo(func() {
+		if running.Add(1) > 1 {
+			overlapped.Add(1)
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+	})
+
+	g.RunWithDelay(5 * time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+	g.Stop()
+
+	if overlapped.Load() != 0 {
+		t.Error("fixed delay runs are overlapped")
+	}
+}
+
+func TestGoMissedRuns(t *testing.T) {
+	for _, missed := range []int{MISSED_RUN_ONCE, MISSED_SKIP} {
+		var counter atomic.Int64
+		g, _ := NewGo(func() { counter.Add(1) })
+
+		// the first run is late, 4 scheduled times were passed.
+		start := time.Now()
+		first := true
+		next := func(last time.Time) time.Time {
+			if first {
+				first = false
+				return start.Add(-350 * time.Millisecond)
+			}
+			return last.Add(100 * time.Millisecond)
+		}
+
+		if err := g.schedule(next, false, ScheduleOptions{Overlap: OVERLAP_ALLOW, Missed: missed}); err != nil {
+			t.Error("schedule failed,", err)
+			return
+		}
+
+		// missed runs are run once or skipped.
+		time.Sleep(25 * time.Millisecond)
+		want := int64(1 - missed)
+		if n := counter.Load(); n != want {
+			t.Error("incorrect number of missed runs, policy:", missed, n)
+		}
+
+		// the next run is on time.
+		time.Sleep(65 * time.Millisecond)
+		if n := counter.Load(); n != want+1 {
+			t.Error("incorrect number of runs, policy:", missed, n)
+		}
+
+		g.Stop()
+	}
+}
@@ -0,0 +1,77 @@
+package easyworker
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+/*
+Statistic of EasyTask, EasyStream & TaskOf.
+Counters are accumulated for all runs.
+*/
+type Stats struct {
+	// Number of attempts were timed out.
+	Timeouts int64
+
+	// Number of goroutines of timed out (or cancelled) attempts are still running.
+	Abandoned int64
+
+	// Number of tasks waited for rate limit before dispatching.
+	RateLimited int64
+
+	// Total time of waiting for rate limit.
+	RateWait time.Duration
+
+	// Number of tasks were dispatched in the last second.
+	Rate int64
+
+	// Number of workers are running.
+	Workers int64
+
+	// Number of times workers were added & retired by autoscaling.
+	ScaleUps   int64
+	ScaleDowns int64
+
+	// Number of tasks shared result of a running task & got result from cache (memoization).
+	Shared    int64
+	CacheHits int64
+
+	// Number of dead workers (panic) were replaced by new workers.
+	Restarts int64
+}
+
+// counters for stats, shared by workers of all runs.
+type counters struct {
+	timeouts  atomic.Int64
+	abandoned atomic.Int64
+
+	rateLimited atomic.Int64
+	rateWait    atomic.Int64
+	dispatched  rateMeter
+
+	workers    atomic.Int64
+	scaleUps   atomic.Int64
+	scaleDowns atomic.Int64
+
+	shared    atomic.Int64
+	cacheHits atomic.Int64
+
+	restarts atomic.Int64
+}
+
+// return current value of counters.
+func (c *counters) snapshot() Stats {
+	return Stats{
+		Timeouts:    c.timeouts.Load(),
+		Abandoned:   c.abandoned.Load(),
+		RateLimited: c.rateLimited.Load(),
+		RateWait:    time.Duration(c.rateWait.Load()),
+		Rate:        c.dispatched.rate(time.Now()),
+		Workers:     c.workers.Load(),
+		ScaleUps:    c.scaleUps.Load(),
+		ScaleDowns:  c.scaleDowns.Load(),
+		Shared:      c.shared.Load(),
+		CacheHits:   c.cacheHits.Load(),
+		Restarts:    c.restarts.Load(),
+	}
+}
@@ -1,8 +1,12 @@
 package easyworker
 
 import (
+	"context"
 	"errors"
 	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 /*
@@ -10,6 +14,8 @@ Store options and runtime data for stream processing.
 Also, struct provides interface for control and processing task.
 */
 type EasyStream struct {
+	monitors
+
 	id int
 
 	// config input by user.
@@ -21,11 +27,29 @@ type EasyStream struct {
 	// output channel.
 	outputCh chan any
 
-	// cmd channel for supervisor.
-	cmdCh chan int
+	// output channel for detail results, used instead of outputCh if is set.
+	resultsCh chan Result[[]any]
+
+	// channel for tasks with priority.
+	priorityCh chan priorityTask
+
+	// closed when stream is stopped.
+	done chan struct{}
+
+	lock  sync.Mutex
+	state atomic.Int64
+
+	// workers of the current run.
+	workers *pool
+
+	// counters for Stats.
+	stats counters
+}
 
-	// store runtime workers.
-	workerList map[int]*worker
+// task with priority, sent by SendWithPriority.
+type priorityTask struct {
+	priority int
+	params   []any
 }
 
 /*
@@ -49,88 +73,252 @@ func NewStream(config Config, taskCh chan []any, resultCh chan any) (ret EasyStr
 		config:     config,
 		inputCh:    taskCh,
 		outputCh:   resultCh,
-		workerList: make(map[int]*worker, config.worker),
+		priorityCh: make(chan priorityTask),
+	}
+	ret.state.Store(STANDBY)
+
+	return
+}
+
+/*
+Make new EasyStream sends detail results.
+Same with NewStream but each result has index (order of receiving task), params, return values, error,
+number of attempts, running time & id of worker ran task.
+
+Example:
+
+	resultCh := make(chan easyworker.Result[[]any])
+	task,_ := NewStreamResult(config, taskCh, resultCh)
+*/
+func NewStreamResult(config Config, taskCh chan []any, resultCh chan Result[[]any]) (ret EasyStream, err error) {
+	if resultCh == nil {
+		err = errors.New("result channel is nil")
+		return
 	}
 
+	if ret, err = NewStream(config, taskCh, nil); err != nil {
+		return
+	}
+	ret.resultsCh = resultCh
+
 	return
 }
 
 /*
 Run func to process stream continuously.
+Stream is stopped by Stop or when all workers were dead.
 
 Example:
 
 	easyStream.Run()
 */
 func (p *EasyStream) Run() (retErr error) {
-	// use for send function's params to worker.
-	inputCh := make(chan msg, p.config.worker)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.state.Load() == RUNNING {
+		return errors.New("EasyStream is running")
+	}
 
-	// use for get result from worker.
-	resultCh := make(chan msg, p.config.worker)
+	done := make(chan struct{})
+	p.done = done
 
-	p.cmdCh = make(chan int)
+	p.state.Store(RUNNING)
+	p.begin()
 
 	// Start workers
-	for i := 0; i < p.config.worker; i++ {
-		opt := &worker{
-			id:         int64(i),
-			fun:        p.config.fun,
-			cmd:        make(chan msg),
-			resultCh:   resultCh,
-			inputCh:    inputCh,
-			retryTimes: p.config.retry,
-		}
-		p.workerList[i] = opt
+	workers := startPool(context.Background(), p.config, p.config.invoker(), &p.stats)
+	p.workers = workers
 
-		go opt.run()
+	// memoization of run, nil is disabled.
+	var cache *memo
+	if p.config.memo != nil {
+		cache = newMemo(p.config.memo)
 	}
 
-	// Send data to worker
+	// Send data to worker, tasks are queued & dispatched by priority.
 	go func() {
+		queue := newPriorityQueue(p.config.aging)
+		queueSize := streamQueueSize(p.config)
+
+		// index of task, order of receiving.
+		index := 0
+
+		// tasks are taken from queue, waiting for rate limit or a worker.
+		th := newThrottle(workers)
+		defer th.stop()
+
+		// in batching mode, timer for sending a batch isn't full.
+		var (
+			batchTimer *time.Timer
+			batchDue   <-chan time.Time
+		)
+		defer func() {
+			if batchTimer != nil {
+				batchTimer.Stop()
+			}
+		}()
+
+		// add new task to queue, duplicated task isn't queued (memoization).
+		// return false if stream was stopped.
+		enqueue := func(params []any, priority int) bool {
+			task := msg{id: index, msgType: iTASK, data: params}
+			index++
+
+			if cache != nil {
+				value, cached, shared := cache.begin(task)
+				if shared {
+					p.stats.shared.Add(1)
+					return true
+				}
+
+				if cached {
+					p.stats.cacheHits.Add(1)
+					result := msg{id: task.id, msgType: iSUCCESS, data: value, input: params, worker: -1, source: RESULT_CACHED}
+					select {
+					case workers.resultCh <- result:
+						return true
+					case <-done:
+						return false
+					}
+				}
+			}
+
+			queue.add(task, priority)
+			return true
+		}
+
 		for {
-			params := <-p.inputCh
-			if printLog {
-				log.Println("stream received new params: ", params)
+			_, hasReady := th.next()
+
+			// in batching mode, wait for batch is full or the oldest task waited for max wait time.
+			batchReady := true
+			if b := workers.batch; b != nil && !hasReady && queue.Len() > 0 && queue.Len() < b.size {
+				if wait := b.wait - time.Since(queue.oldest()); wait > 0 {
+					batchReady = false
+					if batchDue == nil {
+						batchTimer = time.NewTimer(wait)
+						batchDue = batchTimer.C
+					}
+				}
+			}
+
+			// with rate limit or batching, take tasks from queue until a task is ready,
+			// tasks of throttled keys wait for token.
+			if !hasReady && queue.Len() > 0 && batchReady && (workers.limiter != nil || workers.batch != nil) {
+				th.admit(workers.nextTask(queue))
+				continue
+			}
+
+			var (
+				out       chan msg
+				next      msg
+				throttled bool
+			)
+			if task, ok := th.next(); ok {
+				out, next, throttled = workers.inputCh, task, true
+			} else if queue.Len() > 0 && workers.limiter == nil && workers.batch == nil {
+				out, next = workers.inputCh, queue.peek()
+			}
+
+			// stop receiving task if queue is full, each throttled key has a delayed task.
+			inputCh, priorityCh := p.inputCh, p.priorityCh
+			if queue.Len()+th.size() >= queueSize || len(th.delayed) >= queueSize {
+				inputCh, priorityCh = nil, nil
+			}
+
+			select {
+			case params := <-inputCh:
+				if printLog {
+					log.Println("stream received new params: ", params)
+				}
+				if !enqueue(params, 0) {
+					return
+				}
+			case task := <-priorityCh:
+				if printLog {
+					log.Println("stream received new params: ", task.params, ", priority: ", task.priority)
+				}
+				if !enqueue(task.params, task.priority) {
+					return
+				}
+			case <-batchDue:
+				batchDue = nil
+			case <-th.wake():
+				th.release()
+			case out <- next:
+				if throttled {
+					th.pop()
+				} else {
+					queue.next()
+				}
+				workers.dispatched()
+			case <-done:
+				return
 			}
-			inputCh <- msg{id: iSTREAM, msgType: iTASK, data: params}
 		}
 	}()
 
 	// receive result from worker
 	go func() {
 		for {
-			result := <-resultCh
+			var result msg
+
+			select {
+			case result = <-workers.resultCh:
+			case <-done:
+				return
+			}
+
 			switch result.msgType {
-			case iSUCCESS: // task done
-				p.outputCh <- result.data
-			case iERROR: // task failed
-				if printLog {
-					log.Println("stream task", result.id, " is failed, error:", result.data)
-				}
-				// send error to outside.
-				p.outputCh <- result.data
 			case iFATAL_ERROR: // worker panic
 				if printLog {
 					log.Println(result.id, "worker (stream) is fatal error")
 				}
+				if workers.alive() == 0 {
+					p.shutdown(done, STANDBY, GoSignal{Signal: SIGNAL_FAILED, Attempts: 1}, ErrNoWorkers)
+					return
+				}
+				continue
 			case iQUIT: // worker quited
 				if printLog {
 					log.Println(result.id, " exited (stream)")
 				}
+				continue
+			case iCANCEL: // stream was stopped, task isn't run
+				continue
 			}
-		}
-	}()
 
-	// send signal to worker to stop.
-	go func() {
-		for {
-			cmd := <-p.cmdCh
-			switch cmd {
-			case iQUIT:
-				for i, w := range p.workerList {
-					w.cmd <- msg{msgType: iQUIT}
-					delete(p.workerList, i)
+			// result of each task, a batch has many tasks.
+			results := splitBatch(result)
+
+			// duplicated tasks share result.
+			if cache != nil {
+				for _, result := range results {
+					results = append(results, cache.done(result)...)
+				}
+			}
+
+			for _, result := range results {
+				// result is return values of task or error.
+				output := result.data
+				if result.msgType == iERROR && printLog {
+					log.Println("stream task", result.id, " is failed, error:", result.data)
+				}
+
+				if p.resultsCh != nil {
+					select {
+					case p.resultsCh <- newResult[[]any](result):
+					case <-done:
+						return
+					}
+					continue
+				}
+
+				select {
+				case p.outputCh <- output:
+				case <-done:
+					return
 				}
 			}
 		}
@@ -139,15 +327,90 @@ func (p *EasyStream) Run() (retErr error) {
 	return
 }
 
+/*
+Send a task with priority to stream, tasks from input channel are priority 0.
+Tasks are queued if all workers are busy, tasks have higher priority are dispatched first.
+Priority of waiting tasks is increased by aging (see Config.SetPriorityAging).
+Function is blocked until stream receives task.
+
+Example:
+
+	easyStream.SendWithPriority(10, 1, "urgent")
+*/
+func (p *EasyStream) SendWithPriority(priority int, params ...any) error {
+	p.lock.Lock()
+	done := p.done
+	p.lock.Unlock()
+
+	if done == nil || p.state.Load() != RUNNING {
+		return errors.New("EasyStream isn't running")
+	}
+
+	select {
+	case p.priorityCh <- priorityTask{priority: priority, params: params}:
+		return nil
+	case <-done:
+		return errors.New("EasyStream was stopped")
+	}
+}
+
+/*
+Start stream, same with Run. Used for Runnable interface.
+*/
+func (p *EasyStream) Start() error {
+	return p.Run()
+}
+
 /*
 Stop all workers in stream.
-Time to stop depend time user function return.
+Running calls of user function are abandoned, context of them is cancelled.
 */
 func (p *EasyStream) Stop() error {
-	if p.cmdCh != nil {
-		p.cmdCh <- iQUIT
-		return nil
-	} else {
+	p.lock.Lock()
+	done := p.done
+	p.lock.Unlock()
+
+	if done == nil {
 		return errors.New("EasyWorker isn't sart or wrong task's type")
 	}
+
+	p.shutdown(done, STOPPED, GoSignal{Signal: SIGNAL_DONE, Attempts: 1}, nil)
+	return nil
+}
+
+/*
+Return state of stream (STANDBY, RUNNING, STOPPED).
+*/
+func (p *EasyStream) State() int64 {
+	return p.state.Load()
+}
+
+/*
+Return statistic of stream.
+*/
+func (p *EasyStream) Stats() Stats {
+	return p.stats.snapshot()
+}
+
+/*
+Stop workers of a run and send signal to monitors.
+*/
+func (p *EasyStream) shutdown(done chan struct{}, state int64, sig GoSignal, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	select {
+	case <-done:
+		// run was already stopped.
+		return
+	default:
+	}
+
+	close(done)
+
+	// send signal to worker to stop.
+	p.workers.stop()
+
+	p.state.Store(state)
+	p.end(sig, err)
 }
@@ -72,3 +72,77 @@ func TestStream(t *testing.T) {
 
 	eWorker.Stop()
 }
+
+func TestStreamResult(t *testing.T) {
+	inCh := make(chan []any, 1)
+	outCh := make(chan Result[[]any])
+
+	if _, err := NewStreamResult(defaultConfig(strId), inCh, nil); err == nil {
+		t.Error("missed checking result channel")
+	}
+
+	eWorker, err := NewStreamResult(defaultConfig(strId), inCh, outCh)
+	if err != nil {
+		t.Error("create EasyStream failed, ", err)
+		return
+	}
+	defer eWorker.Stop()
+
+	if err = eWorker.Run(); err != nil {
+		t.Error("run stream task failed, ", err)
+		return
+	}
+
+	for i := 1; i <= 3; i++ {
+		inCh <- []any{i, "hello"}
+
+		select {
+		case r := <-outCh:
+			if r.Index != i-1 || r.Args[0] != i || r.Attempts != 1 {
+				t.Error("incorrect result", r)
+			}
+
+			// 3 is panic.
+			if (i == 3) != (r.Err != nil) {
+				t.Error("incorrect error of result", r)
+			}
+		case <-time.After(time.Second):
+			t.Error("timed out")
+			return
+		}
+	}
+}
+
+func TestStreamWorkerRestart(t *testing.T) {
+	inCh := make(chan []any)
+	outCh := make(chan Result[[]any])
+
+	config, _ := NewConfig(failOdd, 1, 0, 0)
+	panicClassifier(&config)
+
+	eWorker, _ := NewStreamResult(config, inCh, outCh)
+	defer eWorker.Stop()
+
+	if err := eWorker.Run(); err != nil {
+		t.Error("run stream task failed, ", err)
+		return
+	}
+
+	for i := 1; i <= 4; i++ {
+		inCh <- []any{i}
+
+		select {
+		case r := <-outCh:
+			if (i%2 == 1) != (r.Err != nil) {
+				t.Error("incorrect result", r)
+			}
+		case <-time.After(time.Second):
+			t.Error("timed out")
+			return
+		}
+	}
+
+	if stats := eWorker.Stats(); stats.Restarts != 2 || eWorker.State() != RUNNING {
+		t.Error("dead worker wasn't restarted", stats)
+	}
+}
@@ -2,8 +2,10 @@ package easyworker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync/atomic"
 )
 
 type key int
@@ -22,10 +24,27 @@ Supervisor privides interface to user with simple APIs.
 You can run multi instance supervisor in your application.
 */
 type Supervisor struct {
-	id       int64
-	children map[int64]*Child
-	cmdCh    chan msg
-	ctx      context.Context
+	id        int64
+	children  map[int64]*Child
+	processes map[int64]*process
+	cmdCh     chan msg
+	ctx       context.Context
+}
+
+/*
+A Runnable is supervised by supervisor.
+*/
+type process struct {
+	id           int64
+	restart_type int
+	runnable     Runnable
+
+	// monitor reference id of runnable.
+	refId int64
+
+	stopped   atomic.Bool
+	restarted atomic.Int64
+	failed    atomic.Int64
 }
 
 /*
@@ -35,9 +54,10 @@ func NewSupervisor() (ret Supervisor) {
 	newId := getNewSupId()
 
 	ret = Supervisor{
-		id:       newId,
-		children: make(map[int64]*Child),
-		cmdCh:    make(chan msg),
+		id:        newId,
+		children:  make(map[int64]*Child),
+		processes: make(map[int64]*process),
+		cmdCh:     make(chan msg),
 	}
 
 	listSup.add(&ret)
@@ -86,17 +106,19 @@ func (s *Supervisor) NewChild(restart int, fun any, params ...any) (id int64, er
 	var (
 		paramsWithCtx []any
 		ctx           context.Context
+		cancel        context.CancelFunc
 	)
 
 	// add context to first param of task
 	if s.ctx != nil {
-		ctx = context.WithValue(s.ctx, CTX_CHILD_ID, childId)
+		ctx, cancel = context.WithCancel(context.WithValue(s.ctx, CTX_CHILD_ID, childId))
 		paramsWithCtx = make([]any, len(params)+1)
 		paramsWithCtx[0] = ctx
 		copy(paramsWithCtx[1:], params)
 	} else {
 		paramsWithCtx = params
 		ctx = nil
+		cancel = nil
 	}
 
 	child := &Child{
@@ -105,6 +127,7 @@ func (s *Supervisor) NewChild(restart int, fun any, params ...any) (id int64, er
 		fun:          fun,
 		params:       paramsWithCtx,
 		ctx:          ctx,
+		cancel:       cancel,
 	}
 
 	s.children[child.id] = child
@@ -127,7 +150,8 @@ func (s *Supervisor) AddChild(child *Child) {
 
 	if s.ctx != nil {
 		// add context to first param of task
-		ctx := context.WithValue(s.ctx, CTX_CHILD_ID, child.id)
+		ctx, cancel := context.WithCancel(context.WithValue(s.ctx, CTX_CHILD_ID, child.id))
+		child.ctx, child.cancel = ctx, cancel
 		paramsWithCtx := make([]any, len(child.params)+1)
 		paramsWithCtx[0] = ctx
 		copy(paramsWithCtx[1:], child.params)
@@ -173,6 +197,115 @@ func (s *Supervisor) GetChild(id int64) *Child {
 	return child
 }
 
+/*
+Add a Runnable (Go, Child, EasyTask, EasyStream, ...) to supervisor.
+Supervisor starts it and restarts it by restart strategy when a run of it done/failed.
+For Child, AddChild is preferred because Child has its own restart loop.
+*/
+func (s *Supervisor) AddRunnable(restart int, r Runnable) (id int64, err error) {
+	if restart < ALWAYS_RESTART || restart > NO_RESTART {
+		err = fmt.Errorf("in correct restart type, input: %d", restart)
+		return
+	}
+
+	if r == nil {
+		err = errors.New("runnable is nil")
+		return
+	}
+
+	p := &process{
+		id:           getNewChildId(),
+		restart_type: restart,
+		runnable:     r,
+	}
+
+	// monitor before start for never missing signal.
+	refId, ch := r.Monitor()
+	p.refId = refId
+
+	if err = r.Start(); err != nil {
+		r.Demonitor(refId)
+		return
+	}
+
+	s.processes[p.id] = p
+	go s.supervise(p, ch)
+
+	id = p.id
+	return
+}
+
+/*
+Get a Runnable from id.
+Return nil if id isn't existed.
+*/
+func (s *Supervisor) GetRunnable(id int64) Runnable {
+	if p, existed := s.processes[id]; existed {
+		return p.runnable
+	}
+	return nil
+}
+
+/*
+Return current state & statistic of a Runnable.
+Return error if id isn't existed.
+*/
+func (s *Supervisor) RunnableStats(id int64) (state int64, restarted int64, failed int64, err error) {
+	p, existed := s.processes[id]
+	if !existed {
+		err = fmt.Errorf("runnable isn't existed, id: %d", id)
+		return
+	}
+
+	state = p.runnable.State()
+	restarted = p.restarted.Load()
+	failed = p.failed.Load()
+
+	return
+}
+
+/*
+Stop & remove a Runnable out of supervisor by id.
+*/
+func (s *Supervisor) RemoveRunnable(id int64) {
+	if p, existed := s.processes[id]; existed {
+		p.stopped.Store(true)
+		p.runnable.Stop()
+		p.runnable.Demonitor(p.refId)
+
+		delete(s.processes, id)
+	}
+}
+
+/*
+Get signals of a Runnable, restart it if needed.
+Loop is exited when monitor channel is closed.
+*/
+func (s *Supervisor) supervise(p *process, ch <-chan GoSignal) {
+	for sig := range ch {
+		if sig.Signal == SIGNAL_FAILED {
+			p.failed.Add(1)
+		}
+
+		if p.stopped.Load() {
+			continue
+		}
+
+		if p.restart_type == NO_RESTART || (p.restart_type == ERROR_RESTART && sig.Signal == SIGNAL_DONE) {
+			continue
+		}
+
+		if printLog {
+			log.Println("restarting runnable:", p.id)
+		}
+
+		p.restarted.Add(1)
+		if err := p.runnable.Start(); err != nil && printLog {
+			log.Println("restart runnable:", p.id, "failed, reason:", err)
+		}
+	}
+}
+
 /*
 Make a goroutine to handle event from children. Restart children if needed.
 */
@@ -213,6 +346,11 @@ func (s *Supervisor) Stop() {
 	for _, child := range s.children {
 		child.stop()
 	}
+
+	for _, p := range s.processes {
+		p.stopped.Store(true)
+		p.runnable.Stop()
+	}
 }
 
 /*
@@ -233,10 +371,15 @@ func (s *Supervisor) Done() {
 	for k := range s.children {
 		delete(s.children, k)
 	}
+
+	for k, p := range s.processes {
+		p.runnable.Demonitor(p.refId)
+		delete(s.processes, k)
+	}
 }
 
 /*
-Return statistics of supervisor.
+Return statistics of supervisor, include children & runnables.
 total: Number of children in supervisor.
 running: Number of children are running.
 stopped: Number of children are stopped.
@@ -255,5 +398,17 @@ func (s *Supervisor) Stats() (total, running, stopped, restarting int) {
 		}
 	}
 
+	total += len(s.processes)
+	for _, p := range s.processes {
+		switch p.runnable.State() {
+		case RUNNING:
+			running++
+		case RESTARTING:
+			restarting++
+		default:
+			stopped++
+		}
+	}
+
 	return
 }
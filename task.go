@@ -1,8 +1,11 @@
 package easyworker
 
 import (
+	"context"
 	"errors"
 	"log"
+	"sync"
+	"sync/atomic"
 )
 
 /*
@@ -10,6 +13,8 @@ Store options and runtime data for task processing.
 Also, struct provides interface for control and processing task.
 */
 type EasyTask struct {
+	monitors
+
 	id int
 
 	// config input by user.
@@ -18,8 +23,30 @@ type EasyTask struct {
 	// task for worker. It's slice of slice of params.
 	inputs [][]any
 
-	// store runtime workers.
-	workerList map[int]*worker
+	// priority of tasks, same index with inputs.
+	priorities []int
+
+	lock  sync.Mutex
+	state atomic.Int64
+
+	// cancel the current run.
+	cancel context.CancelFunc
+
+	// workers of the current run, nil if EasyTask isn't running.
+	workers *pool
+
+	// result of the last run.
+	result []any
+
+	// detail results of the last run.
+	results []Result[[]any]
+
+	// counters for Stats.
+	stats counters
+
+	// checkpoint file & function returns key of task, empty path is no checkpoint.
+	checkpointPath string
+	checkpointKey  func(index int, args []any) string
 }
 
 /*
@@ -35,11 +62,11 @@ func NewTask(config Config) (ret EasyTask, err error) {
 	taskLastId++
 
 	ret = EasyTask{
-		id:         taskLastId,
-		config:     config,
-		inputs:     make([][]any, 0),
-		workerList: make(map[int]*worker, config.worker),
+		id:     taskLastId,
+		config: config,
+		inputs: make([][]any, 0),
 	}
+	ret.state.Store(STANDBY)
 
 	return
 }
@@ -54,96 +81,393 @@ Example:
 	workers.AddParams(1000, "admin")
 */
 func (p *EasyTask) AddTask(i ...any) {
+	p.AddTaskWithPriority(0, i...)
+}
+
+/*
+Add a task with priority. Tasks have higher priority are dispatched first,
+tasks have same priority are dispatched in order of adding. AddTask is priority 0.
+Priority of waiting tasks is increased by aging (see Config.SetPriorityAging).
+If EasyTask is running, task is added to the current run until Close is called or the run is done.
+
+Example:
+
+	workers.AddTask(1, "user")
+	workers.AddTaskWithPriority(10, 2, "urgent")
+*/
+func (p *EasyTask) AddTaskWithPriority(priority int, i ...any) {
 	params := make([]any, 0)
 	params = append(params, i...)
 
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	index := len(p.inputs)
 	p.inputs = append(p.inputs, params)
+	p.priorities = append(p.priorities, priority)
+
+	// add to the current run, task is kept for the next run if the run cannot receive task.
+	if p.workers != nil {
+		p.workers.add(msg{id: index, msgType: iTASK, data: params}, priority)
+	}
+}
+
+/*
+Stop adding tasks to the current run. Tasks are added after Close are run in the next run.
+The current run is done after its tasks done.
+*/
+func (p *EasyTask) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.workers != nil {
+		p.workers.seal()
+	}
+}
+
+/*
+Enable checkpoint, results of succeeded tasks are appended to a JSON Lines file.
+A later run with same file skips tasks were succeeded and returns their stored results (Attempts of Result is 0).
+Each task is identified by key, key = nil is index of task.
+Return values must be encoded by encoding/json, values are decoded to return types of function in config.
+
+Example:
+
+	easyTask.SetCheckpoint("import.jsonl", func(index int, args []any) string {
+		return args[0].(string)
+	})
+*/
+func (p *EasyTask) SetCheckpoint(path string, key func(index int, args []any) string) error {
+	if path == "" {
+		return errors.New("checkpoint path is empty")
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.checkpointPath = path
+	p.checkpointKey = key
+
+	return nil
+}
+
+/*
+Remove all tasks & results, EasyTask can be used for a new batch of tasks.
+Return error if EasyTask is running.
+*/
+func (p *EasyTask) Reset() error {
+	if p.state.Load() == RUNNING {
+		return errors.New("EasyTask is running")
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.inputs = make([][]any, 0)
+	p.priorities = nil
+	p.result = nil
+	p.results = nil
+
+	return nil
 }
 
 /*
 Run func with existed task or waiting a new task.
+Tasks are kept after run, calling Run again re-runs all tasks. Call Reset to remove tasks.
+Result of a failed task is its error, Run returns *TaskErrors for failed tasks by failure policy (see Config.SetFailurePolicy).
 
 Example:
 
 	easyTask.Run()
 */
 func (p *EasyTask) Run() (ret []any, retErr error) {
+	return p.RunContext(context.Background())
+}
+
+/*
+Run func with existed task, same with Run but run can be cancelled by context.
+If context is cancelled (or deadline is exceeded), pending tasks aren't dispatched,
+context passed to user function is cancelled (if the first param of function is context.Context)
+and Run returns promptly, running calls are abandoned (see Abandoned of Stats).
+Tasks without result are marked with an error wraps ErrCancelled, Run returns an error wraps ErrCancelled & cause of context.
+Workers of a run are stopped before Run returns.
+
+Example:
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	easyTask.RunContext(ctx)
+*/
+func (p *EasyTask) RunContext(ctx context.Context) (ret []any, retErr error) {
+	if ctx == nil {
+		return make([]any, 0), errors.New("context is nil")
+	}
+
+	if !p.state.CompareAndSwap(STANDBY, RUNNING) {
+		return make([]any, 0), errors.New("EasyTask is running or stopped")
+	}
+	p.begin()
+
+	var results []Result[[]any]
+	ret, results, retErr = p.run(ctx)
+	p.finish(ret, results, retErr)
+
+	return
+}
+
+/*
+Start run tasks in background. Used for Runnable interface.
+Call Wait to wait for tasks done, then get results by GetResult.
+*/
+func (p *EasyTask) Start() error {
+	if !p.state.CompareAndSwap(STANDBY, RUNNING) {
+		return errors.New("EasyTask is running or stopped")
+	}
+	p.begin()
+
+	go func() {
+		ret, results, err := p.run(context.Background())
+		p.finish(ret, results, err)
+	}()
+
+	return nil
+}
+
+/*
+Stop EasyTask, it cannot run again.
+Running tasks are cancelled.
+*/
+func (p *EasyTask) Stop() error {
+	p.state.Store(STOPPED)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.cancel != nil {
+		p.cancel()
+	}
+
+	return nil
+}
+
+/*
+Return state of EasyTask (STANDBY, RUNNING, STOPPED).
+*/
+func (p *EasyTask) State() int64 {
+	return p.state.Load()
+}
+
+/*
+Get results from the last run.
+Result of each task is []any or an error.
+*/
+func (p *EasyTask) GetResult() []any {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.result
+}
+
+/*
+Get detail results from the last run, same order with tasks.
+Each result has params, return values, error, number of attempts, running time & id of worker ran task.
+
+Example:
+
+	easyTask.Run()
+
+	for _, r := range easyTask.GetResults() {
+		fmt.Println("task", r.Index, "args:", r.Args, "attempts:", r.Attempts, "time:", r.Duration(), "worker:", r.WorkerId)
+	}
+*/
+func (p *EasyTask) GetResults() []Result[[]any] {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.results
+}
+
+/*
+Return statistic of EasyTask.
+*/
+func (p *EasyTask) Stats() Stats {
+	return p.stats.snapshot()
+}
+
+/*
+Store result of a run and send signal to monitors.
+*/
+func (p *EasyTask) finish(ret []any, results []Result[[]any], err error) {
+	p.lock.Lock()
+	p.result = ret
+	p.results = results
+	p.lock.Unlock()
+
+	// keep STOPPED state if EasyTask was stopped while running.
+	p.state.CompareAndSwap(RUNNING, STANDBY)
+
+	sig := GoSignal{Signal: SIGNAL_DONE, Attempts: 1}
+	if err != nil {
+		sig.Signal = SIGNAL_FAILED
+	}
+	p.end(sig, err)
+}
+
+/*
+Run tasks and wait for all tasks done.
+Return results in compatible format ([]any or error for each task) & detail results.
+*/
+func (p *EasyTask) run(ctx context.Context) (ret []any, results []Result[[]any], retErr error) {
 	ret = make([]any, 0)
 
-	if len(p.inputs) < 1 {
-		retErr = errors.New("need params to run")
+	var failErr error
+
+	ch, err := p.stream(ctx, func(err error) {
+		failErr = err
+	})
+	if err != nil {
+		retErr = err
 		return
 	}
 
-	// use for send function's params to worker.
-	inputCh := make(chan msg, p.config.worker)
-
-	// use for get result from worker.
-	resultCh := make(chan msg, p.config.worker)
+	for result := range ch {
+		// tasks can be added while running.
+		for len(results) <= result.Index {
+			results = append(results, Result[[]any]{})
+			ret = append(ret, nil)
+		}
 
-	// Start workers
-	for i := 0; i < p.config.worker; i++ {
-		opt := &worker{
-			id:         int64(i),
-			fun:        p.config.fun,
-			cmd:        make(chan msg),
-			resultCh:   resultCh,
-			inputCh:    inputCh,
-			retryTimes: p.config.retry,
+		results[result.Index] = result
+		if result.Err != nil {
+			ret[result.Index] = result.Err
+		} else {
+			ret[result.Index] = result.Value
 		}
-		p.workerList[i] = opt
+	}
 
-		go opt.run()
+	// error of the first failed task in fail fast mode.
+	retErr = failErr
+	if retErr == nil {
+		retErr = taskErrors(p.config.failure, results)
 	}
 
-	// Send data to worker
-	go func() {
-		for index, params := range p.inputs {
-			inputCh <- msg{id: index, msgType: iTASK, data: params}
+	return
+}
+
+/*
+Run tasks and return a channel for receiving results in completion order.
+Each result is tagged with index of task, Err of Result is set if task failed.
+Channel is closed when all tasks done. Results aren't stored for GetResult.
+
+Example:
+
+	ch, _ := easyTask.RunAsync()
+
+	for result := range ch {
+		fmt.Println("task", result.Index, "result:", result.Value, "error:", result.Err)
+	}
+*/
+func (p *EasyTask) RunAsync() (<-chan Result[[]any], error) {
+	if !p.state.CompareAndSwap(STANDBY, RUNNING) {
+		return nil, errors.New("EasyTask is running or stopped")
+	}
+	p.begin()
+
+	ch, err := p.stream(context.Background(), func(err error) {
+		p.finish(nil, nil, err)
+	})
+	if err != nil {
+		p.finish(nil, nil, err)
+		return nil, err
+	}
+
+	return ch, nil
+}
+
+/*
+Start workers & send tasks to workers.
+Results are sent to returned channel, after all tasks done onDone is called (with error in fail fast mode)
+then channel is closed.
+*/
+func (p *EasyTask) stream(ctx context.Context, onDone func(err error)) (<-chan Result[[]any], error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.inputs) < 1 {
+		return nil, errors.New("need params to run")
+	}
+
+	var cp *checkpoint
+	if p.checkpointPath != "" {
+		// in batching mode, return types of function are unknown.
+		var fun any
+		if p.config.batch == nil {
+			fun = p.config.fun
 		}
-	}()
 
-	resultMap := map[int]any{}
-
-	// receive result from worker
-	for {
-		result := <-resultCh
-		switch result.msgType {
-		case iSUCCESS: // task done
-			resultMap[result.id] = result.data
-		case iERROR: // task failed
-			if printLog {
-				log.Println("task", result.id, " is failed, error:", result.data)
-			}
-			resultMap[result.id] = result.data
-		case iFATAL_ERROR: // worker panic
-			if printLog {
-				log.Println(result.id, "worker is fatal error")
-			}
-		case iQUIT: // worker quited\
-			if printLog {
-				log.Println(result.id, " exited")
-			}
+		var err error
+		if cp, err = openCheckpoint(p.checkpointPath, p.checkpointKey, fun); err != nil {
+			return nil, err
 		}
+	}
 
-		if len(resultMap) == len(p.inputs) {
-			break
+	// results of tasks were succeeded in checkpoint.
+	restored := make([]Result[[]any], 0)
+
+	tasks := make([]queuedTask, 0, len(p.inputs))
+	for i, params := range p.inputs {
+		if cp != nil {
+			if values, ok := cp.lookup(i, params); ok {
+				restored = append(restored, Result[[]any]{Index: i, Value: values, Args: params, WorkerId: -1})
+				continue
+			}
 		}
+		tasks = append(tasks, queuedTask{task: msg{id: i, msgType: iTASK, data: params}, priority: p.priorities[i]})
 	}
 
-	// send signal to worker to stop.
+	out := make(chan Result[[]any], p.config.worker)
+
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+
+	// Start workers
+	workers := startPool(ctx, p.config, p.config.invoker(), &p.stats)
+	p.workers = workers
+
 	go func() {
-		for _, w := range p.workerList {
-			w.cmd <- msg{msgType: iQUIT}
+		for _, r := range restored {
+			out <- r
 		}
-	}()
 
-	ret = make([]any, len(resultMap))
+		err := workers.runBatch(tasks, func(result msg) {
+			r := newResult[[]any](result)
+			if cp != nil && r.Err == nil {
+				if err := cp.record(r.Index, r.Args, r.Value); err != nil && printLog {
+					log.Println("checkpoint, cannot record result, error:", err)
+				}
+			}
+			out <- r
+		})
 
-	for k, v := range resultMap {
+		if cp != nil {
+			cp.close()
+		}
 
-		ret[k] = v
-	}
+		p.lock.Lock()
+		p.workers = nil
+		p.lock.Unlock()
 
-	return
+		// stop workers, context of user function is cancelled.
+		workers.stop()
+		cancel()
+
+		if onDone != nil {
+			onDone(err)
+		}
+
+		close(out)
+	}()
+
+	return out, nil
 }
@@ -1,8 +1,12 @@
 package easyworker
 
 import (
+	"context"
+	"errors"
 	"log"
+	"runtime"
 	"testing"
+	"time"
 )
 
 func TestNoTask(t *testing.T) {
@@ -86,3 +90,527 @@ func TestTaskStop(t *testing.T) {
 		log.Println("task result:", r)
 	}
 }
+
+func TestTaskRunAsync(t *testing.T) {
+	config, _ := NewConfig(addWithPanic, 2, 0, 0)
+	eWorker, _ := NewTask(config)
+
+	num := 10
+	for i := 1; i <= num; i++ {
+		eWorker.AddTask(i, i)
+	}
+
+	ch, err := eWorker.RunAsync()
+	if err != nil {
+		t.Error("run task failed, ", err)
+		return
+	}
+
+	if eWorker.State() != RUNNING {
+		t.Error("incorrect state")
+	}
+
+	received := map[int]bool{}
+	for result := range ch {
+		received[result.Index] = true
+
+		i := result.Index + 1
+		if i%3 == 0 {
+			if result.Err == nil {
+				t.Error("missed error of task", result.Index)
+			}
+		} else if result.Err != nil || result.Value[0].(int) != i+i {
+			t.Error("incorrect result of task", result.Index, result)
+		}
+	}
+
+	if len(received) != num {
+		t.Error("missed results, received:", len(received))
+	}
+
+	eWorker.Wait()
+	if eWorker.State() != STANDBY {
+		t.Error("incorrect state after run")
+	}
+}
+
+func TestTaskRunAsyncNoTask(t *testing.T) {
+	eWorker, _ := NewTask(defaultConfig(add))
+
+	if _, err := eWorker.RunAsync(); err == nil {
+		t.Error("easyworker run without task")
+	}
+
+	if eWorker.State() != STANDBY {
+		t.Error("incorrect state after failed run")
+	}
+}
+
+func TestTaskRunContextTimeout(t *testing.T) {
+	slow := func(a int) int {
+		time.Sleep(50 * time.Millisecond)
+		return a
+	}
+
+	config, _ := NewConfig(slow, 1, 0, 0)
+	eWorker, _ := NewTask(config)
+
+	for i := 0; i < 20; i++ {
+		eWorker.AddTask(i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	r, err := eWorker.RunContext(ctx)
+
+	if !errors.Is(err, ErrCancelled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("RunContext must return error of cancelled run, ", err)
+	}
+
+	if time.Since(start) > 300*time.Millisecond {
+		t.Error("RunContext doesn't return promptly")
+	}
+
+	cancelled := 0
+	for _, v := range r {
+		if e, ok := v.(error); ok {
+			if !errors.Is(e, ErrCancelled) || !errors.Is(e, context.DeadlineExceeded) {
+				t.Error("incorrect error", e)
+			}
+			cancelled++
+		}
+	}
+
+	if cancelled < 15 || cancelled == len(r) {
+		t.Error("incorrect number of cancelled tasks", cancelled)
+	}
+}
+
+func TestTaskRunContextAbandon(t *testing.T) {
+	// function ignores context.
+	slow := func(a int) int {
+		time.Sleep(2 * time.Second)
+		return a
+	}
+
+	config, _ := NewConfig(slow, 2, 0, 0)
+	eWorker, _ := NewTask(config)
+	eWorker.AddTask(1)
+	eWorker.AddTask(2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	r, err := eWorker.RunContext(ctx)
+
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Error("RunContext doesn't return promptly", d)
+	}
+
+	if !errors.Is(err, ErrCancelled) {
+		t.Error("RunContext must return error of cancelled run, ", err)
+	}
+
+	for _, v := range r {
+		if e, ok := v.(error); !ok || !errors.Is(e, ErrCancelled) {
+			t.Error("incorrect result", v)
+		}
+	}
+
+	if stats := eWorker.Stats(); stats.Abandoned != 2 {
+		t.Error("running calls must be abandoned", stats)
+	}
+}
+
+func TestTaskRunContextFunc(t *testing.T) {
+	wait := func(ctx context.Context, a int) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Second):
+			return nil
+		}
+	}
+
+	config, _ := NewConfig(wait, 2, 0, 0)
+	eWorker, _ := NewTask(config)
+
+	eWorker.AddTask(1)
+	eWorker.AddTask(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	done := make(chan []any)
+	go func() {
+		r, _ := eWorker.RunContext(ctx)
+		done <- r
+	}()
+
+	select {
+	case r := <-done:
+		for _, v := range r {
+			if e, ok := v.(error); !ok || !errors.Is(e, context.Canceled) {
+				t.Error("incorrect result", v)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Error("RunContext doesn't return after context is cancelled")
+	}
+}
+
+func TestTaskFailFast(t *testing.T) {
+	config, _ := NewConfig(addWithPanic, 1, 0, 0)
+	config.SetFailFast(true)
+
+	eWorker, _ := NewTask(config)
+
+	for i := 1; i <= 10; i++ {
+		eWorker.AddTask(i, i)
+	}
+
+	r, err := eWorker.Run()
+	if err == nil {
+		t.Error("missed error of the first failed task")
+		return
+	}
+
+	if r[0].([]any)[0].(int) != 2 || r[1].([]any)[0].(int) != 4 || r[2].(error) != err {
+		t.Error("incorrect partial results", r)
+	}
+
+	cancelled := 0
+	for _, v := range r[3:] {
+		if e, ok := v.(error); ok && errors.Is(e, ErrCancelled) {
+			cancelled++
+		}
+	}
+
+	// tasks were taken by worker before cancelling can be done.
+	if cancelled < 5 {
+		t.Error("remaining tasks aren't cancelled", r)
+	}
+}
+
+func TestTaskTimeout(t *testing.T) {
+	stuck := func(a int) int {
+		if a > 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		return a
+	}
+
+	config, _ := NewConfig(stuck, 2, 1, 0)
+	config.SetTimeout(20 * time.Millisecond)
+
+	eWorker, _ := NewTask(config)
+
+	eWorker.AddTask(1)
+	eWorker.AddTask(2)
+	eWorker.AddTask(3)
+
+	start := time.Now()
+	r, _ := eWorker.Run()
+
+	if time.Since(start) > 150*time.Millisecond {
+		t.Error("workers are blocked by stuck tasks")
+	}
+
+	if r[0].([]any)[0].(int) != 1 {
+		t.Error("incorrect result", r[0])
+	}
+
+	for _, v := range r[1:] {
+		if e, ok := v.(error); !ok || !errors.Is(e, ErrTimeout) {
+			t.Error("incorrect result", v)
+		}
+	}
+
+	stats := eWorker.Stats()
+	if stats.Timeouts != 4 || stats.Abandoned < 1 {
+		t.Error("incorrect stats", stats)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	if eWorker.Stats().Abandoned != 0 {
+		t.Error("abandoned goroutines aren't counted down", eWorker.Stats())
+	}
+}
+
+func TestTaskGetResults(t *testing.T) {
+	config, _ := NewConfig(addWithPanic, 2, 1, 0)
+	eWorker, _ := NewTask(config)
+
+	for i := 1; i <= 4; i++ {
+		eWorker.AddTask(i, i)
+	}
+
+	if _, err := eWorker.Run(); err != nil {
+		t.Error("run task failed, ", err)
+		return
+	}
+
+	results := eWorker.GetResults()
+	if len(results) != 4 {
+		t.Error("incorrect number of results", results)
+		return
+	}
+
+	for i, r := range results {
+		if r.Index != i || len(r.Args) != 2 || r.Args[0] != i+1 || r.End.Before(r.Start) {
+			t.Error("incorrect result", r)
+		}
+
+		if r.WorkerId < 0 || r.WorkerId > 1 {
+			t.Error("incorrect worker id", r.WorkerId)
+		}
+
+		// a%3 == 0 is panic.
+		if i == 2 {
+			if r.Err == nil || r.Attempts != 2 {
+				t.Error("incorrect failed result", r)
+			}
+		} else if r.Err != nil || r.Attempts != 1 || r.Value[0] != 2*(i+1) {
+			t.Error("incorrect success result", r)
+		}
+	}
+}
+
+func TestTaskRunTwice(t *testing.T) {
+	eWorker, _ := NewTask(defaultConfig(add))
+	eWorker.AddTask(1, 2)
+
+	for i := 0; i < 2; i++ {
+		r, err := eWorker.Run()
+		if err != nil || len(r) != 1 || r[0].([]any)[0] != 3 {
+			t.Error("incorrect result of run", i, r, err)
+		}
+	}
+
+	if err := eWorker.Reset(); err != nil {
+		t.Error("reset task failed, ", err)
+	}
+
+	if len(eWorker.GetResult()) != 0 {
+		t.Error("results aren't cleared")
+	}
+
+	if _, err := eWorker.Run(); err == nil {
+		t.Error("tasks aren't cleared")
+	}
+
+	eWorker.AddTask(3, 4)
+	r, _ := eWorker.Run()
+	if len(r) != 1 || r[0].([]any)[0] != 7 {
+		t.Error("incorrect result after reset", r)
+	}
+}
+
+func TestTaskAddWhileRunning(t *testing.T) {
+	release := make(chan struct{})
+	fn := func(a int) int {
+		if a == 0 {
+			<-release
+		}
+		return a
+	}
+
+	config, _ := NewConfig(fn, 2, 0, 0)
+	eWorker, _ := NewTask(config)
+	eWorker.AddTask(0)
+
+	ch, err := eWorker.RunAsync()
+	if err != nil {
+		t.Error("run task failed, ", err)
+		return
+	}
+
+	if err := eWorker.Reset(); err == nil {
+		t.Error("reset a running task")
+	}
+
+	eWorker.AddTask(1)
+	eWorker.AddTask(2)
+	eWorker.Close()
+
+	// added after Close, kept for the next run.
+	eWorker.AddTask(3)
+	close(release)
+
+	sum := 0
+	for r := range ch {
+		sum += r.Value[0].(int)
+	}
+
+	if sum != 3 {
+		t.Error("tasks are added while running aren't run", sum)
+	}
+
+	r, _ := eWorker.Run()
+	if len(r) != 4 || r[3].([]any)[0] != 3 {
+		t.Error("incorrect result of the next run", r)
+	}
+}
+
+func TestTaskWorkersStopped(t *testing.T) {
+	eWorker, _ := NewTask(defaultConfig(add))
+	eWorker.AddTask(1, 2)
+
+	base := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		eWorker.Run()
+	}
+
+	if n := runtime.NumGoroutine(); n > base {
+		t.Error("workers aren't stopped after run", base, n)
+	}
+}
+
+// fail for odd number, classifier panics for that error (worker is dead).
+func panicClassifier(config *Config) {
+	config.SetRetryPolicy(RetryPolicy{MaxAttempts: 2})
+	config.SetRetryable(func(err error) bool {
+		panic(err)
+	})
+}
+
+func failOdd(n int) int {
+	if n%2 == 1 {
+		panic("odd number")
+	}
+	return n
+}
+
+func TestTaskWorkerRestart(t *testing.T) {
+	config, _ := NewConfig(failOdd, 2, 0, 0)
+	panicClassifier(&config)
+
+	eWorker, _ := NewTask(config)
+	for i := 0; i < 10; i++ {
+		eWorker.AddTask(i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		_, err := eWorker.Run()
+		if err != nil {
+			t.Error("run task failed,", err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Error("run is hung")
+		return
+	}
+
+	for _, r := range eWorker.GetResults() {
+		var p *PanicError
+		if (r.Index%2 == 1) != errors.As(r.Err, &p) {
+			t.Error("incorrect result of task", r.Index, r.Err)
+		}
+	}
+
+	if stats := eWorker.Stats(); stats.Restarts != 5 || stats.Workers != 0 {
+		t.Error("dead workers weren't restarted", stats)
+	}
+}
+
+func TestTaskWorkerNoRestart(t *testing.T) {
+	config, _ := NewConfig(failOdd, 1, 0, 0)
+	panicClassifier(&config)
+
+	if err := config.SetWorkerRestart(-1); err == nil {
+		t.Error("missed checking restart type")
+	}
+	config.SetWorkerRestart(NO_RESTART)
+
+	eWorker, _ := NewTask(config)
+	for i := 0; i < 5; i++ {
+		eWorker.AddTask(i)
+	}
+
+	_, err := eWorker.Run()
+	if !errors.Is(err, ErrNoWorkers) {
+		t.Error("run must be stopped when all workers were dead,", err)
+	}
+
+	results := eWorker.GetResults()
+	if len(results) != 5 || results[0].Err != nil || results[1].Err == nil {
+		t.Error("incorrect results", results)
+	}
+	for _, r := range results[2:] {
+		if !errors.Is(r.Err, ErrCancelled) {
+			t.Error("pending task must be cancelled", r.Index, r.Err)
+		}
+	}
+
+	if stats := eWorker.Stats(); stats.Restarts != 0 {
+		t.Error("dead worker was restarted", stats)
+	}
+}
+
+func TestTaskFailurePolicy(t *testing.T) {
+	config, _ := NewConfig(failOdd, 2, 0, 0)
+
+	if err := config.SetFailurePolicy(FAILURE_ALL + 1); err == nil {
+		t.Error("missed checking failure policy")
+	}
+
+	eWorker, _ := NewTask(config)
+	for i := 0; i < 5; i++ {
+		eWorker.AddTask(i)
+	}
+
+	// default policy, failed tasks are only in results.
+	if _, err := eWorker.Run(); err != nil {
+		t.Error("failed tasks must be ignored,", err)
+	}
+
+	eWorker.config.SetFailurePolicy(FAILURE_ANY)
+	ret, err := eWorker.Run()
+
+	var taskErrs *TaskErrors
+	if !errors.As(err, &taskErrs) {
+		t.Error("run must return TaskErrors,", err)
+		return
+	}
+
+	if idx := taskErrs.Indices(); len(idx) != 2 || idx[0] != 1 || idx[1] != 3 || taskErrs.Total != 5 {
+		t.Error("incorrect failed tasks", idx, taskErrs.Total)
+	}
+
+	var p *PanicError
+	if !errors.As(err, &p) || p.Value != "odd number" {
+		t.Error("errors of tasks must be unwrapped", err)
+	}
+
+	if len(ret) != 5 || ret[1] != taskErrs.Errors[0].Err {
+		t.Error("incorrect results", ret)
+	}
+
+	if msg := err.Error(); msg != "2 of 5 tasks failed: task 1: user function was panic, odd number; task 3: user function was panic, odd number" {
+		t.Error("incorrect error message", msg)
+	}
+
+	// partial failure is success.
+	eWorker.config.SetFailurePolicy(FAILURE_ALL)
+	if _, err := eWorker.Run(); err != nil {
+		t.Error("partial failure must be success,", err)
+	}
+
+	eWorker.Reset()
+	eWorker.AddTask(1)
+	if _, err := eWorker.Run(); !errors.As(err, &taskErrs) || len(taskErrs.Errors) != 1 {
+		t.Error("all tasks failed, run must return TaskErrors,", err)
+	}
+}
@@ -0,0 +1,145 @@
+package easyworker
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// Failed tasks are only reported in results, Run doesn't return error for them.
+	FAILURE_IGNORE = iota
+
+	// Run returns TaskErrors if any task failed.
+	FAILURE_ANY
+
+	// Run returns TaskErrors only if all tasks failed, partial failure is success.
+	FAILURE_ALL
+)
+
+const (
+	// maximum number of task errors in message of TaskErrors.
+	iMAX_LISTED_ERRORS = 10
+)
+
+/*
+Error of a failed task, item of TaskErrors.
+*/
+type TaskError struct {
+	// Index of task, same order with adding task.
+	Index int
+
+	// Error of task.
+	Err error
+}
+
+func (e TaskError) Error() string {
+	return fmt.Sprintf("task %d: %v", e.Index, e.Err)
+}
+
+func (e TaskError) Unwrap() error {
+	return e.Err
+}
+
+/*
+Aggregated error of failed tasks in a run, returned by EasyTask.Run with failure policy (see Config.SetFailurePolicy).
+errors.Is & errors.As check errors of all failed tasks.
+
+Example:
+
+	_, err := easyTask.Run()
+
+	var taskErrs *easyworker.TaskErrors
+	if errors.As(err, &taskErrs) {
+		for _, e := range taskErrs.Errors {
+			fmt.Println("task", e.Index, "failed:", e.Err)
+		}
+	}
+*/
+type TaskErrors struct {
+	// Errors of failed tasks, order by index of task.
+	Errors []TaskError
+
+	// Number of tasks of run.
+	Total int
+}
+
+func (e *TaskErrors) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d of %d tasks failed", len(e.Errors), e.Total)
+
+	for i, err := range e.Errors {
+		if i == iMAX_LISTED_ERRORS {
+			fmt.Fprintf(&b, "; and %d more", len(e.Errors)-i)
+			break
+		}
+
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		b.WriteString(err.Error())
+	}
+
+	return b.String()
+}
+
+// used by errors.Is & errors.As.
+func (e *TaskErrors) Unwrap() []error {
+	ret := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		ret[i] = err
+	}
+	return ret
+}
+
+/*
+Return indices of failed tasks.
+*/
+func (e *TaskErrors) Indices() []int {
+	ret := make([]int, len(e.Errors))
+	for i, err := range e.Errors {
+		ret[i] = err.Index
+	}
+	return ret
+}
+
+/*
+Set policy for failed tasks of EasyTask.Run (FAILURE_IGNORE, FAILURE_ANY, FAILURE_ALL), default is FAILURE_IGNORE.
+With FAILURE_ANY, Run returns *TaskErrors if any task failed. With FAILURE_ALL, Run returns *TaskErrors only if all tasks failed.
+Results of all tasks are still returned. In fail fast mode, error of the first failed task is returned instead.
+
+Example:
+
+	config.SetFailurePolicy(easyworker.FAILURE_ANY)
+*/
+func (c *Config) SetFailurePolicy(policy int) error {
+	if policy < FAILURE_IGNORE || policy > FAILURE_ALL {
+		return fmt.Errorf("incorrect failure policy, input: %d", policy)
+	}
+
+	c.failure = policy
+	return nil
+}
+
+/*
+Return aggregated error of failed tasks by failure policy, nil if run is success.
+*/
+func taskErrors(policy int, results []Result[[]any]) error {
+	if policy == FAILURE_IGNORE {
+		return nil
+	}
+
+	ret := &TaskErrors{Total: len(results)}
+	for _, r := range results {
+		if r.Err != nil {
+			ret.Errors = append(ret.Errors, TaskError{Index: r.Index, Err: r.Err})
+		}
+	}
+
+	if len(ret.Errors) == 0 || (policy == FAILURE_ALL && len(ret.Errors) < ret.Total) {
+		return nil
+	}
+
+	return ret
+}
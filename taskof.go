@@ -0,0 +1,212 @@
+package easyworker
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+/*
+Result of a task, used by TaskOf, EasyTask & EasyStream.
+*/
+type Result[Out any] struct {
+	// Index of task, same order with adding task.
+	Index int
+
+	// Value returned by user function.
+	Value Out
+
+	// Error of task, nil if task done successfully.
+	Err error
+
+	// Number of attempts were run for task, 0 if task was cancelled before running.
+	Attempts int
+
+	// Input of task. Params of task for EasyTask & EasyStream, a single input for TaskOf.
+	Args []any
+
+	// Time of the first attempt started & the last attempt ended, zero if task was cancelled before running.
+	Start, End time.Time
+
+	// Id of worker ran task (index of worker in pool), -1 if task wasn't received by a worker.
+	WorkerId int64
+
+	// Source of result (RESULT_COMPUTED, RESULT_SHARED, RESULT_CACHED), see Config.SetMemoize.
+	Source int
+}
+
+/*
+Return running time of task, include retries.
+*/
+func (r Result[Out]) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+/*
+Convert result from worker to Result.
+*/
+func newResult[Out any](result msg) (ret Result[Out]) {
+	ret.Index = result.id
+	ret.Attempts = result.attempts
+	ret.Start, ret.End = result.start, result.end
+	ret.WorkerId = result.worker
+	ret.Source = result.source
+
+	switch args := result.input.(type) {
+	case nil:
+	case []any:
+		ret.Args = args
+	default:
+		ret.Args = []any{args}
+	}
+
+	switch result.msgType {
+	case iSUCCESS:
+		// data is nil if Out is an interface & function returns nil.
+		ret.Value, _ = result.data.(Out)
+	case iERROR, iCANCEL:
+		ret.Err, _ = result.data.(error)
+	}
+
+	return
+}
+
+/*
+Generic, type-safe version of EasyTask.
+User function is called directly without reflect.
+*/
+type TaskOf[In, Out any] struct {
+	id int
+
+	// config input by user, function in config isn't used.
+	config Config
+
+	// function, define by user.
+	fun func(context.Context, In) (Out, error)
+
+	// task for worker.
+	inputs []In
+
+	// counters for Stats.
+	stats counters
+}
+
+/*
+Make new TaskOf.
+Config is made by NewPoolConfig (or NewConfig, function in config is ignored).
+
+Example:
+
+	config, _ := NewPoolConfig(3, 0, 0)
+	task, _ := NewTaskOf(func(ctx context.Context, n int) (string, error) {
+		return strconv.Itoa(n), nil
+	}, config)
+*/
+func NewTaskOf[In, Out any](fun func(context.Context, In) (Out, error), config Config) (ret *TaskOf[In, Out], err error) {
+	if fun == nil {
+		err = errors.New("function is nil")
+		return
+	}
+
+	if config.worker < 1 {
+		err = errors.New("config isn't made by NewPoolConfig or NewConfig")
+		return
+	}
+
+	if config.batch != nil {
+		err = errors.New("batching mode isn't supported by TaskOf")
+		return
+	}
+
+	// auto incremental number.
+	taskLastId++
+
+	ret = &TaskOf[In, Out]{
+		id:     taskLastId,
+		config: config,
+		fun:    fun,
+		inputs: make([]In, 0),
+	}
+
+	return
+}
+
+/*
+Add a task (input of user function).
+*/
+func (t *TaskOf[In, Out]) Add(input In) {
+	t.inputs = append(t.inputs, input)
+}
+
+/*
+Run all tasks and wait for all tasks done.
+Results are same order with tasks, Err of Result is set if task failed.
+If context is cancelled, Run returns promptly with an error wraps ErrCancelled, tasks without result are marked with ErrCancelled.
+In fail fast mode, error of the first failed task is returned with partial results.
+
+Example:
+
+	results, err := task.Run(context.Background())
+*/
+func (t *TaskOf[In, Out]) Run(ctx context.Context) (ret []Result[Out], retErr error) {
+	ret = make([]Result[Out], 0)
+
+	if len(t.inputs) < 1 {
+		retErr = errors.New("need params to run")
+		return
+	}
+
+	ret = make([]Result[Out], len(t.inputs))
+
+	retErr = t.each(ctx, func(result Result[Out]) {
+		ret[result.Index] = result
+	})
+
+	return
+}
+
+/*
+Run all tasks, onResult is called for each result in completion order (in goroutine of caller).
+Workers are stopped before function returns.
+*/
+func (t *TaskOf[In, Out]) each(ctx context.Context, onResult func(result Result[Out])) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	tasks := make([]queuedTask, len(t.inputs))
+	for i, input := range t.inputs {
+		tasks[i] = queuedTask{task: msg{id: i, msgType: iTASK, data: input}}
+	}
+
+	// Start workers
+	workers := startPool(ctx, t.config, t.invoker(), &t.stats)
+
+	// stop workers, also if onResult was panic.
+	defer workers.stop()
+
+	return workers.runBatch(tasks, func(result msg) {
+		onResult(newResult[Out](result))
+	})
+}
+
+/*
+Return statistic of TaskOf.
+*/
+func (t *TaskOf[In, Out]) Stats() Stats {
+	return t.stats.snapshot()
+}
+
+/*
+Return function for calling user function directly.
+*/
+func (t *TaskOf[In, Out]) invoker() invoker {
+	fun := t.fun
+	return func(ctx context.Context, data any) (any, error) {
+		return safeCall(func() (any, error) {
+			// nil input of interface type is the zero In.
+			in, _ := data.(In)
+			return fun(ctx, in)
+		})
+	}
+}
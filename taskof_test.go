@@ -0,0 +1,108 @@
+package easyworker
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func square(ctx context.Context, n int) (int, error) {
+	if n < 0 {
+		return 0, errors.New("negative number")
+	}
+	return n * n, nil
+}
+
+func TestTaskOfIncorrect(t *testing.T) {
+	config, _ := NewPoolConfig(2, 0, 0)
+
+	if _, err := NewTaskOf[int, int](nil, config); err == nil {
+		t.Error("missed checking nil function")
+	}
+
+	if _, err := NewTaskOf(square, Config{}); err == nil {
+		t.Error("missed checking config")
+	}
+
+	task, _ := NewTaskOf(square, config)
+	if _, err := task.Run(context.Background()); err == nil {
+		t.Error("task run without input")
+	}
+}
+
+func TestTaskOf(t *testing.T) {
+	config, _ := NewPoolConfig(3, 0, 0)
+
+	task, err := NewTaskOf(square, config)
+	if err != nil {
+		t.Error("create task failed, ", err)
+		return
+	}
+
+	for i := -1; i < 10; i++ {
+		task.Add(i)
+	}
+
+	results, err := task.Run(context.Background())
+	if err != nil {
+		t.Error("run task failed, ", err)
+		return
+	}
+
+	if len(results) != 11 || results[0].Err == nil {
+		t.Error("incorrect results", results)
+		return
+	}
+
+	for i, r := range results[1:] {
+		if r.Err != nil || r.Index != i+1 || r.Value != i*i {
+			t.Error("incorrect result", r)
+		}
+	}
+}
+
+func TestTaskOfPanic(t *testing.T) {
+	config, _ := NewPoolConfig(1, 2, 0)
+
+	counter := 0
+	task, _ := NewTaskOf(func(ctx context.Context, s string) (error, error) {
+		counter++
+		if counter < 3 {
+			panic("test panic")
+		}
+		return nil, nil
+	}, config)
+
+	task.Add("hello")
+
+	results, _ := task.Run(context.Background())
+	if results[0].Err != nil || results[0].Value != nil || counter != 3 {
+		t.Error("incorrect result", results[0], counter)
+	}
+}
+
+func TestTaskOfNilInput(t *testing.T) {
+	config, _ := NewPoolConfig(1, 0, 0)
+
+	task, _ := NewTaskOf(func(ctx context.Context, in any) (any, error) {
+		return in, nil
+	}, config)
+
+	task.Add(nil)
+
+	results, err := task.Run(context.Background())
+	if err != nil || results[0].Err != nil || results[0].Value != nil {
+		t.Error("incorrect result of nil input", results, err)
+	}
+}
+
+func TestPoolConfig(t *testing.T) {
+	if _, err := NewPoolConfig(0, 0, 0); err == nil {
+		t.Error("incorrect number of worker is passed")
+	}
+
+	config, err := NewPoolConfig(2, 1, 0)
+	if err != nil || config.fun != nil || config.worker != 2 {
+		t.Error("incorrect config, ", err)
+	}
+}
@@ -1,6 +1,8 @@
 package easyworker
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"time"
 )
@@ -23,6 +25,20 @@ type msg struct {
 	id      int
 	msgType int
 	data    any
+
+	// number of attempts were run for task.
+	attempts int
+
+	// input of task, id of worker ran task & time of running task.
+	input      any
+	worker     int64
+	start, end time.Time
+
+	// tasks of a batch (batching mode).
+	items []msg
+
+	// source of result (memoization), RESULT_COMPUTED by default.
+	source int
 }
 
 // worker's information.
@@ -30,16 +46,32 @@ type worker struct {
 	// worker's id
 	id int64
 
-	// retry time, define by user.
-	retryTimes int
+	// retry policy, define by user.
+	retry RetryPolicy
+
+	// call function define by user.
+	call invoker
+
+	// context of run, passed to call.
+	ctx context.Context
 
-	// sleep time between re-run.
-	retrySleep int
+	// timeout for each call, 0 is no timeout.
+	timeout time.Duration
 
-	// function, define by user.
-	fun any
+	// stats of owner.
+	stats *counters
 
-	// command channel, supervisor uses to send command to worker.
+	// counters of running tasks in pool.
+	progress *progressCounters
+
+	// idle time before worker asks for retiring (autoscaling), 0 is never.
+	idle   time.Duration
+	retire func(id int64) bool
+
+	// called when worker was panic, pool replaces worker.
+	dead func(id int64)
+
+	// command channel, supervisor uses to send command to worker. Worker exits if channel is closed.
 	cmd chan msg
 
 	// input channel, worker receives task (params) then run with fun.
@@ -55,59 +87,241 @@ after start worker will wait task from supervisor.
 after task done, worker will send result back to supervisor with id of task.
 */
 func (w *worker) run() {
+	var (
+		task msg
+		ret  any
+		err  error
+
+		// task is running, start time & number of attempts.
+		running  bool
+		start    time.Time
+		attempts int
+	)
+
 	defer func() {
 		if r := recover(); r != nil {
 			if printLog {
 				log.Println(w.id, ", worker was panic, ", r)
 			}
-			w.resultCh <- msg{id: int(w.id), msgType: iFATAL_ERROR, data: r}
+
+			// replace worker before answering the running task, pool may be stopped after the last result.
+			if w.dead != nil {
+				w.dead(w.id)
+			}
+
+			// answer the running task, owner doesn't wait for it.
+			if running {
+				if attempts > 1 {
+					w.progress.retrying.Add(-1)
+				}
+				w.progress.inFlight.Add(-1)
+
+				w.send(msg{
+					id:       task.id,
+					msgType:  iERROR,
+					data:     &PanicError{Value: r},
+					attempts: attempts,
+					input:    task.data,
+					worker:   w.id,
+					start:    start,
+					end:      time.Now(),
+					items:    task.items,
+				})
+			}
+
+			w.send(msg{id: int(w.id), msgType: iFATAL_ERROR, data: r})
 		}
 	}()
 
-	var (
-		task msg
-		ret  []any
-		err  error
-	)
-
 	for {
+		var (
+			idle  <-chan time.Time
+			timer *time.Timer
+		)
+		if w.idle > 0 {
+			timer = time.NewTimer(w.idle)
+			idle = timer.C
+		}
+
 		select {
 		case task = <-w.inputCh:
-		case cmd := <-w.cmd:
+		case cmd, ok := <-w.cmd:
 			// receive a quit signal.
-			if cmd.msgType == iQUIT {
+			if !ok || cmd.msgType == iQUIT {
 				if printLog {
 					log.Println(w.id, "is exited")
 				}
 				return
 			}
+		case <-idle:
+			if w.retire(w.id) {
+				if printLog {
+					log.Println(w.id, "is retired")
+				}
+				return
+			}
+			continue
+		}
+
+		if timer != nil {
+			timer.Stop()
 		}
 
 		switch task.msgType {
 		case iTASK:
-			args := task.data.([]any)
-
-			for i := 0; i <= w.retryTimes; i++ {
-				if i > 0 {
-					time.Sleep(time.Millisecond * time.Duration(w.retrySleep))
-					if printLog {
-						log.Println(w.id, ", retry(", i, ") function with last args")
-					}
+			// run was cancelled, task isn't run.
+			if w.ctx.Err() != nil {
+				err = fmt.Errorf("%w, %w", ErrCancelled, context.Cause(w.ctx))
+				if !w.send(msg{id: task.id, msgType: iCANCEL, data: err, input: task.data, worker: w.id, items: task.items}) {
+					return
 				}
-				ret, err = invokeFun(w.fun, args...)
+				continue
+			}
+
+			var delay time.Duration
+			start, attempts, running = time.Now(), 0, true
+
+			w.progress.inFlight.Add(1)
+
+			for {
+				attempts++
+
+				ret, err = w.invoke(task.data)
 				if err == nil {
 					break
 				}
+
+				d, ok := w.retry.next(attempts, delay, start, err)
+				if !ok || !w.wait(d) {
+					break
+				}
+				delay = d
+
+				if attempts == 1 {
+					w.progress.retrying.Add(1)
+				}
+
+				if printLog {
+					log.Println(w.id, ", retry(", attempts, ") function with last args")
+				}
+			}
+
+			if attempts > 1 {
+				w.progress.retrying.Add(-1)
 			}
+			w.progress.inFlight.Add(-1)
+			running = false
 
+			result := msg{
+				id:       task.id,
+				msgType:  iSUCCESS,
+				data:     ret,
+				attempts: attempts,
+				input:    task.data,
+				worker:   w.id,
+				start:    start,
+				end:      time.Now(),
+				items:    task.items,
+			}
 			if err != nil {
 				if printLog {
 					log.Println(w.id, ", call function failed, error: ", err)
 				}
-				w.resultCh <- msg{id: task.id, msgType: iERROR, data: err}
-			} else {
-				w.resultCh <- msg{id: task.id, msgType: iSUCCESS, data: ret}
+				result.msgType = iERROR
+				result.data = err
+			}
+
+			if !w.send(result) {
+				if printLog {
+					log.Println(w.id, "is exited")
+				}
+				return
 			}
 		}
 	}
 }
+
+/*
+Wait before retry.
+Return false if run was cancelled while waiting.
+*/
+func (w *worker) wait(d time.Duration) bool {
+	if w.ctx.Err() != nil {
+		return false
+	}
+
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-w.ctx.Done():
+		return false
+	}
+}
+
+/*
+Call user function with timeout (if has).
+If timeout is expired or run is cancelled, goroutine of call is abandoned and worker moves on.
+*/
+func (w *worker) invoke(data any) (any, error) {
+	ctx, cancel := w.ctx, context.CancelFunc(func() {})
+	if w.timeout > 0 {
+		ctx, cancel = context.WithTimeout(w.ctx, w.timeout)
+	}
+	defer cancel()
+
+	type result struct {
+		ret any
+		err error
+	}
+
+	// buffered, goroutine can exit after worker moved on.
+	done := make(chan result, 1)
+
+	go func() {
+		ret, err := w.call(ctx, data)
+		done <- result{ret, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.ret, r.err
+	case <-ctx.Done():
+	}
+
+	w.stats.abandoned.Add(1)
+	go func() {
+		<-done
+		w.stats.abandoned.Add(-1)
+	}()
+
+	if w.ctx.Err() != nil {
+		return nil, fmt.Errorf("%w, %w", ErrCancelled, context.Cause(w.ctx))
+	}
+
+	w.stats.timeouts.Add(1)
+	if printLog {
+		log.Println(w.id, ", call function was timed out after", w.timeout)
+	}
+
+	return nil, fmt.Errorf("%w after %s", ErrTimeout, w.timeout)
+}
+
+/*
+Send result to supervisor.
+Return false if worker receives quit signal while waiting supervisor takes result.
+*/
+func (w *worker) send(result msg) bool {
+	select {
+	case w.resultCh <- result:
+		return true
+	case cmd, ok := <-w.cmd:
+		return ok && cmd.msgType != iQUIT
+	}
+}